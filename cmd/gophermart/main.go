@@ -1,78 +1,466 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/Guldana11/gophermart/database"
+	"github.com/Guldana11/gophermart/events"
 	"github.com/Guldana11/gophermart/handlers"
+	"github.com/Guldana11/gophermart/logging"
+	"github.com/Guldana11/gophermart/metrics"
 	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
 	"github.com/Guldana11/gophermart/service"
+	"github.com/Guldana11/gophermart/sqlite"
+	"github.com/Guldana11/gophermart/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// fatal logs msg and stops the server during start-up.
+func fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// storageMemory is the STORAGE_BACKEND that keeps everything in memory.
+const storageMemory = "memory"
+
+// EVENT_PUBLISHER values.
+const (
+	eventPublisherNotify  = "notify"
+	eventPublisherWebhook = "webhook"
+)
+
+// shutdownDelay keeps serving after readiness starts failing, so load
+// balancers notice before connections are refused.
+const shutdownDelay = 5 * time.Second
+
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	envErr := godotenv.Load()
+
+	logger, err := logging.FromEnv(os.Stdout, os.Getenv)
+	if err != nil {
+		fatal(slog.Default(), "invalid logging configuration", "error", err)
+	}
+	slog.SetDefault(logger)
+	if envErr != nil {
+		logger.Info("no .env file found, using system environment variables")
+	}
+
+	// STORAGE_BACKEND=memory runs the member API without a database, see
+	// runInMemory. Otherwise the DATABASE_URI scheme picks Postgres or SQLite.
+	storage := os.Getenv("STORAGE_BACKEND")
+	if storage != "" && storage != storageMemory {
+		fatal(logger, "invalid STORAGE_BACKEND", "value", storage)
+	}
+	migrateOnly := len(os.Args) > 1 && os.Args[1] == "migrate"
+
+	dbURL := os.Getenv("DATABASE_URI")
+	if dbURL == "" && (storage != storageMemory || migrateOnly) {
+		fatal(logger, "DATABASE_URI is not set")
+	}
+
+	if migrateOnly {
+		if err := runMigrate(dbURL, os.Args[2:], os.Stdout); err != nil {
+			fatal(logger, "migrate failed", "error", err)
+		}
+		return
 	}
 
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET is not set")
+		fatal(logger, "JWT_SECRET is not set")
 	}
 	middleware.SetJWTKey([]byte(jwtSecret))
 
-	dbURL := os.Getenv("DATABASE_URI")
-	if dbURL == "" {
-		log.Fatal("DATABASE_URI is not set")
+	// AUTO_MIGRATE=false leaves schema changes to "gophermart migrate"; the
+	// server then refuses to become ready until the schema is current.
+	autoMigrate := true
+	if v := os.Getenv("AUTO_MIGRATE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			fatal(logger, "invalid AUTO_MIGRATE", "value", v)
+		}
+		autoMigrate = b
 	}
 
 	accrualAddr := os.Getenv("ACCRUAL_SYSTEM_ADDRESS")
 	if accrualAddr == "" {
-		log.Fatal("ACCRUAL_SYSTEM_ADDRESS is empty")
+		fatal(logger, "ACCRUAL_SYSTEM_ADDRESS is empty")
+	}
+
+	// The accrual worker asks the accrual system about unfinished orders every
+	// ACCRUAL_POLL_INTERVAL.
+	accrualPoll := time.Second
+	if v := os.Getenv("ACCRUAL_POLL_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			fatal(logger, "invalid ACCRUAL_POLL_INTERVAL", "value", v)
+		}
+		accrualPoll = d
+	}
+
+	holdTTL := 15 * time.Minute
+	if v := os.Getenv("HOLD_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			fatal(logger, "invalid HOLD_TTL", "value", v, "error", err)
+		}
+		if d <= 0 {
+			fatal(logger, "invalid HOLD_TTL: must be a positive duration", "value", v)
+		}
+		holdTTL = d
+	}
+
+	// Audit events are kept for a year unless AUDIT_RETENTION says otherwise;
+	// 0 keeps them forever.
+	auditRetention := 365 * 24 * time.Hour
+	if v := os.Getenv("AUDIT_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			fatal(logger, "invalid AUDIT_RETENTION", "value", v)
+		}
+		auditRetention = d
+	}
+
+	// Outbox events always go to the webhooks members and merchants register.
+	// EVENT_PUBLISHER adds one more destination: "notify" for pg_notify on
+	// EVENT_NOTIFY_CHANNEL, "webhook" for POSTs to EVENT_WEBHOOK_URL.
+	eventPublisher := os.Getenv("EVENT_PUBLISHER")
+	eventChannel := os.Getenv("EVENT_NOTIFY_CHANNEL")
+	if eventChannel == "" {
+		eventChannel = "gophermart_events"
+	}
+	eventWebhookURL := os.Getenv("EVENT_WEBHOOK_URL")
+	switch eventPublisher {
+	case "", eventPublisherNotify:
+	case eventPublisherWebhook:
+		if eventWebhookURL == "" {
+			fatal(logger, "EVENT_WEBHOOK_URL is not set")
+		}
+	default:
+		fatal(logger, "invalid EVENT_PUBLISHER", "value", eventPublisher)
 	}
 
-	if err := database.Migrate(dbURL); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	// Published events are kept for a week unless OUTBOX_RETENTION says
+	// otherwise; 0 keeps them forever.
+	outboxRetention := 7 * 24 * time.Hour
+	if v := os.Getenv("OUTBOX_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			fatal(logger, "invalid OUTBOX_RETENTION", "value", v)
+		}
+		outboxRetention = d
 	}
-	log.Println("Migrations applied successfully")
 
-	dbPool, err := database.InitDB(dbURL)
+	referralCfg, err := service.ReferralConfigFromEnv(os.Getenv)
+	if err != nil {
+		fatal(logger, "invalid referral configuration", "error", err)
+	}
+
+	webhookCfg, err := service.WebhookConfigFromEnv(os.Getenv)
+	if err != nil {
+		fatal(logger, "invalid webhook configuration", "error", err)
+	}
+
+	tracingCfg, err := tracing.ConfigFromEnv(os.Getenv)
+	if err != nil {
+		fatal(logger, "invalid tracing configuration", "error", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingCfg, os.Stdout)
+	if err != nil {
+		fatal(logger, "failed to set up tracing", "error", err)
+	}
+	defer shutdownTracing(context.Background())
+	logger.Info("tracing configured", "exporter", tracingCfg.Exporter)
+
+	switch {
+	case storage == storageMemory:
+		runInMemory(ctx, stop, logger, accrualAddr, accrualPoll)
+		return
+	case sqlite.IsURI(dbURL):
+		runSQLite(ctx, stop, logger, dbURL, accrualAddr, accrualPoll, autoMigrate)
+		return
+	}
+
+	schemaVersion, err := database.LatestMigrationVersion()
+	if err != nil {
+		fatal(logger, "failed to read embedded migrations", "error", err)
+	}
+	poolCfg, err := database.PoolConfigFromEnv(os.Getenv)
 	if err != nil {
-		log.Fatalf("Failed to init db pool: %v", err)
+		fatal(logger, "invalid database pool configuration", "error", err)
+	}
+	dbPool, err := database.Connect(ctx, dbURL, poolCfg, logger)
+	if err != nil {
+		fatal(logger, "failed to init db pool", "error", err)
 	}
 	defer dbPool.Close()
-	log.Println("Database connection established")
+	logger.Info("database connection established")
+
+	if autoMigrate {
+		if err := database.Migrate(dbURL); err != nil {
+			fatal(logger, "failed to migrate database", "error", err)
+		}
+		logger.Info("migrations applied", "version", schemaVersion)
+	}
+
+	m := metrics.New()
+	m.RegisterPool(dbPool)
 
 	userRepo := database.NewUserRepo(dbPool)
 	orderRepo := database.NewOrderRepo(dbPool)
+	m.RegisterOrderCounts(orderRepo, 2*time.Second)
+	holdRepo := database.NewHoldRepo(dbPool)
+	merchantRepo := database.NewMerchantRepo(dbPool)
+	apiKeyRepo := database.NewAPIKeyRepo(dbPool)
+	adminRepo := database.NewAdminRepo(dbPool)
+	campaignRepo := database.NewCampaignRepo(dbPool)
+	accrualRuleRepo := database.NewAccrualRuleRepo(dbPool)
+	referralRepo := database.NewReferralRepo(dbPool)
+	voucherRepo := database.NewVoucherRepo(dbPool)
+	auditRepo := database.NewAuditRepo(dbPool)
+	txManager := database.NewTxManager(dbPool)
+	txManager.SetLogger(logger)
 
 	userSvc := service.NewUserService(userRepo)
+	userSvc.SetLogger(logger)
+	userSvc.SetTxManager(txManager)
 	orderSvc := service.NewOrderService(orderRepo)
-	loyaltySvc := service.NewLoyaltyService(accrualAddr)
+	loyaltySvc := service.NewLoyaltyService(accrualAddr, m)
 	balanceSvc := service.NewBalanceService(userRepo)
+	balanceSvc.SetObserver(m)
+	holdSvc := service.NewHoldService(holdRepo, holdTTL)
+	holdSvc.SetLogger(logger)
+	merchantSvc := service.NewMerchantService(merchantRepo, orderSvc)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo)
+	adminSvc := service.NewAdminService(adminRepo, userRepo, orderRepo)
+	campaignSvc := service.NewCampaignService(campaignRepo, orderRepo)
+	userSvc.SetCampaigns(campaignSvc)
+	accrualRuleSvc := service.NewAccrualRuleService(accrualRuleRepo)
+	referralSvc := service.NewReferralService(referralRepo, referralCfg)
+	userSvc.SetReferrals(referralSvc)
+	voucherSvc := service.NewVoucherService(voucherRepo)
+	auditSvc := service.NewAuditService(auditRepo, auditRetention)
+	auditSvc.SetLogger(logger)
+
+	accrualSync := service.NewAccrualSyncService(orderRepo, loyaltySvc)
+	accrualSync.SetLogger(logger)
+	accrualSync.SetCampaigns(campaignSvc)
+	accrualSync.SetBonuses(accrualRuleSvc)
+	accrualSync.SetReferrals(referralSvc)
+	accrualSync.SetTxManager(txManager)
+
+	go holdSvc.RunExpirySweeper(ctx, time.Minute)
+	go auditSvc.RunRetention(ctx, time.Hour)
+	go accrualSync.Run(ctx, accrualPoll)
+
+	webhookSvc := service.NewWebhookService(database.NewWebhookRepo(dbPool), txManager, webhookCfg)
+	webhookSvc.SetLogger(logger)
+	go webhookSvc.Run(ctx, time.Second)
+
+	publishers := service.EventPublishers{webhookSvc}
+	switch eventPublisher {
+	case eventPublisherNotify:
+		publishers = append(publishers, database.NewNotifyPublisher(dbPool, eventChannel))
+	case eventPublisherWebhook:
+		publishers = append(publishers, events.NewWebhookPublisher(eventWebhookURL, os.Getenv("EVENT_WEBHOOK_SECRET")))
+	}
+	relay := service.NewOutboxRelay(database.NewOutboxRepo(dbPool), txManager, publishers, outboxRetention)
+	relay.SetLogger(logger)
+	go relay.Run(ctx, time.Second)
 
 	orderHandler := handlers.NewOrderHandler(orderSvc, loyaltySvc)
 	userHandler := handlers.NewUserHandler(balanceSvc)
+	userHandler.HoldService = holdSvc
+	userHandler.Audit = auditSvc
+	holdHandler := handlers.NewHoldHandler(holdSvc)
+	merchantHandler := handlers.NewMerchantHandler(merchantSvc)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc)
+	adminHandler := handlers.NewAdminHandler(adminSvc)
+	campaignHandler := handlers.NewCampaignHandler(campaignSvc)
+	accrualRuleHandler := handlers.NewAccrualRuleHandler(accrualRuleSvc)
+	referralHandler := handlers.NewReferralHandler(referralSvc)
+	voucherHandler := handlers.NewVoucherHandler(voucherSvc)
+	auditHandler := handlers.NewAuditHandler(auditSvc)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc)
+
+	healthHandler := handlers.NewHealthHandler(2*time.Second,
+		handlers.HealthCheck{Name: "database", Critical: true, Check: dbPool.Ping},
+		migrationsCheck(schemaVersion, func(ctx context.Context) (uint, bool, error) {
+			return database.SchemaVersion(ctx, dbPool)
+		}),
+		// Orders still upload while the accrual system is down and the
+		// accrual worker scores them once it is back, so it does not gate
+		// readiness.
+		handlers.HealthCheck{Name: "accrual", Check: func(ctx context.Context) error {
+			return service.PingAccrualSystem(ctx, accrualAddr)
+		}},
+	)
+	healthHandler.SetLogger(logger)
 
-	r := gin.Default()
-	r.POST("/api/user/register", handlers.RegisterHandler(userSvc))
-	r.POST("/api/user/login", handlers.LoginHandler(userSvc))
+	r := newRouter(logger, m, healthHandler)
+
+	r.POST("/api/user/register", handlers.RegisterHandler(userSvc, auditSvc))
+	r.POST("/api/user/login", handlers.LoginHandler(userSvc, auditSvc))
 
 	auth := r.Group("/api")
-	auth.Use(middleware.AuthMiddlewareJWT())
+	auth.Use(middleware.APIKeyAuth(apiKeySvc), middleware.AuthMiddlewareJWT(), middleware.RejectBlocked(adminSvc))
+	{
+		ordersRead := middleware.RequireScope(models.ScopeOrdersRead)
+		ordersWrite := middleware.RequireScope(models.ScopeOrdersWrite)
+		balanceRead := middleware.RequireScope(models.ScopeBalanceRead)
+		balanceWrite := middleware.RequireScope(models.ScopeBalanceWrite)
+
+		auth.POST("/user/orders", ordersWrite, orderHandler.UploadOrderHandler)
+		auth.GET("/user/orders", ordersRead, orderHandler.GetOrdersHandler)
+
+		auth.GET("/user/balance", balanceRead, userHandler.GetBalance)
+		auth.POST("/user/balance/withdraw", balanceWrite, userHandler.Withdraw)
+		auth.GET("/user/withdrawals", balanceRead, userHandler.GetWithdrawals)
+
+		auth.POST("/user/balance/holds", balanceWrite, holdHandler.AuthorizeHold)
+		auth.GET("/user/balance/holds", balanceRead, holdHandler.GetHolds)
+		auth.POST("/user/balance/holds/:order/capture", balanceWrite, holdHandler.CaptureHold)
+		auth.POST("/user/balance/holds/:order/void", balanceWrite, holdHandler.VoidHold)
+
+		auth.GET("/user/referrals", balanceRead, referralHandler.ListReferrals)
+		auth.POST("/user/vouchers/redeem", balanceWrite, voucherHandler.Redeem)
+
+		routeWebhooks(auth.Group("/user/webhooks", middleware.RequireScope(models.ScopeWebhooks)), webhookHandler)
+	}
+
+	// Key management needs a real session: an API key cannot mint more keys.
+	keys := r.Group("/api/user/keys")
+	keys.Use(middleware.AuthMiddlewareJWT(), middleware.RejectBlocked(adminSvc))
+	{
+		keys.POST("", apiKeyHandler.CreateKey)
+		keys.GET("", apiKeyHandler.ListKeys)
+		keys.DELETE("/:id", apiKeyHandler.RevokeKey)
+	}
+
+	admin := r.Group("/api/admin")
+	admin.Use(handlers.AuditAdminActions(auditSvc), middleware.APIKeyAuth(apiKeySvc), middleware.AuthMiddlewareJWT(), middleware.RejectBlocked(adminSvc))
 	{
-		auth.POST("/user/orders", orderHandler.UploadOrderHandler)
-		auth.GET("/user/orders", orderHandler.GetOrdersHandler)
+		staff := middleware.RequireRole(models.RoleSupport, models.RoleAdmin)
+		adminOnly := middleware.RequireRole(models.RoleAdmin)
+
+		admin.GET("/users", staff, adminHandler.SearchUsers)
+		admin.GET("/users/:id", staff, adminHandler.GetAccount)
+		admin.GET("/users/:id/orders", staff, adminHandler.GetOrders)
+		admin.GET("/users/:id/withdrawals", staff, adminHandler.GetWithdrawals)
+		admin.GET("/users/:id/adjustments", staff, adminHandler.GetAdjustments)
+		admin.GET("/users/:id/grants", staff, campaignHandler.GetUserGrants)
+		admin.GET("/campaigns", staff, campaignHandler.ListCampaigns)
+		admin.GET("/accrual-rules", staff, accrualRuleHandler.ListRules)
+		admin.GET("/vouchers/report", staff, voucherHandler.Report)
 
-		auth.GET("/user/balance", userHandler.GetBalance)
-		auth.POST("/user/balance/withdraw", userHandler.Withdraw)
-		auth.GET("/user/withdrawals", userHandler.GetWithdrawals)
+		admin.POST("/users/:id/block", adminOnly, adminHandler.BlockUser)
+		admin.POST("/users/:id/unblock", adminOnly, adminHandler.UnblockUser)
+		admin.PUT("/users/:id/role", adminOnly, adminHandler.SetRole)
+		admin.POST("/users/:id/adjustments", adminOnly, adminHandler.AdjustBalance)
+		admin.POST("/campaigns", adminOnly, campaignHandler.CreateCampaign)
+		admin.POST("/campaigns/:id/disable", adminOnly, campaignHandler.DisableCampaign)
+		admin.POST("/accrual-rules", adminOnly, accrualRuleHandler.CreateRule)
+		admin.POST("/accrual-rules/:id/disable", adminOnly, accrualRuleHandler.DisableRule)
+		admin.POST("/vouchers", adminOnly, voucherHandler.CreateBatch)
+		admin.GET("/audit", adminOnly, auditHandler.ListEvents)
+		admin.POST("/keys", adminOnly, apiKeyHandler.CreateServiceKey)
+	}
+
+	// A store issues its keys with its own token, never with a key.
+	merchantKeys := r.Group("/api/merchant/keys")
+	merchantKeys.Use(middleware.MerchantAuth(merchantSvc))
+	{
+		merchantKeys.POST("", apiKeyHandler.CreateMerchantKey)
 	}
 
-	log.Println("server started at :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal(err)
+	merchant := r.Group("/api/merchant")
+	merchant.Use(middleware.APIKeyAuth(apiKeySvc), middleware.MerchantAuth(merchantSvc))
+	{
+		merchant.POST("/purchases", middleware.RequireScope(models.ScopeOrdersWrite), merchantHandler.RegisterPurchase)
+
+		routeWebhooks(merchant.Group("/webhooks", middleware.RequireScope(models.ScopeWebhooks)), webhookHandler)
+	}
+
+	serve(ctx, stop, logger, r, healthHandler)
+}
+
+// routeWebhooks serves webhook management to members and merchants alike.
+func routeWebhooks(g *gin.RouterGroup, h *handlers.WebhookHandler) {
+	g.POST("", h.CreateEndpoint)
+	g.GET("", h.ListEndpoints)
+	g.DELETE("/:id", h.DeleteEndpoint)
+	g.POST("/:id/enable", h.EnableEndpoint)
+	g.GET("/:id/deliveries", h.ListDeliveries)
+	g.POST("/:id/deliveries/:delivery/replay", h.ReplayDelivery)
+}
+
+// migrationsCheck fails readiness while the schema is dirty or older than the
+// embedded migrations. A newer schema is fine: migrations stay backward
+// compatible, so an older replica keeps serving during a rolling deploy.
+func migrationsCheck(want uint, schemaVersion func(ctx context.Context) (uint, bool, error)) handlers.HealthCheck {
+	return handlers.HealthCheck{Name: "migrations", Critical: true, Check: func(ctx context.Context) error {
+		version, dirty, err := schemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty || version < want {
+			return fmt.Errorf("schema at version %d (dirty: %t), expected at least %d", version, dirty, want)
+		}
+		return nil
+	}}
+}
+
+// newRouter sets up the middleware every request goes through and the
+// operational endpoints.
+func newRouter(logger *slog.Logger, m *metrics.Metrics, health *handlers.HealthHandler) *gin.Engine {
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.Use(middleware.Tracing(), middleware.RequestID(), middleware.AccessLog(logger), middleware.Metrics(m), middleware.ErrorHandler(logger))
+	r.NoRoute(middleware.NotFound)
+	r.NoMethod(middleware.MethodNotAllowed)
+
+	r.GET("/metrics", gin.WrapH(m.Handler()))
+	r.GET("/healthz", health.Live)
+	r.GET("/readyz", health.Ready)
+	return r
+}
+
+// serve runs the server until ctx is cancelled, then fails readiness, waits
+// shutdownDelay and drains in-flight requests. stop restores default signal
+// handling, so a second signal ends the process at once.
+func serve(ctx context.Context, stop context.CancelFunc, logger *slog.Logger, handler http.Handler, health *handlers.HealthHandler) {
+	srv := &http.Server{Addr: ":8080", Handler: handler}
+	go func() {
+		logger.Info("server started", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal(logger, "server stopped", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	logger.Info("shutting down")
+	health.SetShuttingDown()
+	time.Sleep(shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("graceful shutdown failed", "error", err)
 	}
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		version uint
+		dirty   bool
+		err     error
+		wantErr bool
+	}{
+		{"current", 19, false, nil, false},
+		{"newer schema from a rolling deploy", 20, false, nil, false},
+		{"older schema", 18, false, nil, true},
+		{"dirty", 19, true, nil, true},
+		{"lookup failed", 0, false, errors.New("connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := migrationsCheck(19, func(context.Context) (uint, bool, error) {
+				return tt.version, tt.dirty, tt.err
+			})
+			assert.True(t, check.Critical)
+			err := check.Check(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil, "Check() error = %v", err)
+		})
+	}
+}
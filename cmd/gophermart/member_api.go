@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Guldana11/gophermart/handlers"
+	"github.com/Guldana11/gophermart/memory"
+	"github.com/Guldana11/gophermart/metrics"
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/Guldana11/gophermart/sqlite"
+	"github.com/gin-gonic/gin"
+)
+
+// orderStore is what the member API needs from order storage.
+type orderStore interface {
+	repository.OrderRepository
+	metrics.OrderCounter
+}
+
+// runInMemory serves the member API from process memory for local
+// development. Nothing survives a restart.
+func runInMemory(ctx context.Context, stop context.CancelFunc, logger *slog.Logger, accrualAddr string, accrualPoll time.Duration) {
+	logger.Warn("using in-memory storage, data is lost on restart")
+
+	db := memory.New()
+	runMemberAPI(ctx, stop, logger, accrualAddr, accrualPoll, memory.NewUserRepo(db), memory.NewOrderRepo(db))
+}
+
+// runSQLite serves the member API from a SQLite file, for single-node sites
+// that do not run Postgres.
+func runSQLite(ctx context.Context, stop context.CancelFunc, logger *slog.Logger, dbURL, accrualAddr string, accrualPoll time.Duration, autoMigrate bool) {
+	schemaVersion, err := sqlite.LatestMigrationVersion()
+	if err != nil {
+		fatal(logger, "failed to read embedded migrations", "error", err)
+	}
+	if autoMigrate {
+		if err := sqlite.Migrate(dbURL); err != nil {
+			fatal(logger, "failed to migrate database", "error", err)
+		}
+		logger.Info("migrations applied", "version", schemaVersion)
+	}
+
+	db, err := sqlite.Open(ctx, dbURL)
+	if err != nil {
+		fatal(logger, "failed to open database", "error", err)
+	}
+	defer db.Close()
+	logger.Info("using SQLite storage")
+
+	runMemberAPI(ctx, stop, logger, accrualAddr, accrualPoll, sqlite.NewUserRepo(db), sqlite.NewOrderRepo(db),
+		handlers.HealthCheck{Name: "database", Critical: true, Check: db.PingContext},
+		migrationsCheck(schemaVersion, func(ctx context.Context) (uint, bool, error) {
+			return sqlite.SchemaVersion(ctx, db)
+		}),
+	)
+}
+
+// memberAPI is the member API and the accrual worker that scores its orders.
+type memberAPI struct {
+	handler http.Handler
+	health  *handlers.HealthHandler
+	sync    *service.AccrualSyncService
+}
+
+// runMemberAPI serves the member API and runs its accrual worker until ctx is
+// done.
+func runMemberAPI(ctx context.Context, stop context.CancelFunc, logger *slog.Logger, accrualAddr string, accrualPoll time.Duration, userRepo repository.UserRepository, orderRepo orderStore, checks ...handlers.HealthCheck) {
+	api := newMemberAPI(logger, accrualAddr, userRepo, orderRepo, checks...)
+	go api.sync.Run(ctx, accrualPoll)
+	serve(ctx, stop, logger, api.handler, api.health)
+}
+
+// unservedRoutes are the parts of the API that need Postgres. The member API
+// answers them with 501 so that a client can tell a missing feature from a
+// mistyped path.
+var unservedRoutes = []string{
+	"/api/user/balance/holds",
+	"/api/user/referrals",
+	"/api/user/vouchers",
+	"/api/user/webhooks",
+	"/api/user/keys",
+	"/api/merchant",
+	"/api/admin",
+}
+
+// newMemberAPI serves registration, login, orders, balance and withdrawals
+// from storage that implements only users and orders. It has a reduced scope:
+//
+//   - there are no campaigns, so registration grants no sign-up bonus, and
+//     accrual rules and referral rewards add nothing to an order;
+//   - holds, vouchers, webhooks, API keys, merchants and the admin API are
+//     not served (see unservedRoutes), and nothing is audited;
+//   - no domain events are recorded.
+func newMemberAPI(logger *slog.Logger, accrualAddr string, userRepo repository.UserRepository, orderRepo orderStore, checks ...handlers.HealthCheck) *memberAPI {
+	m := metrics.New()
+	m.RegisterOrderCounts(orderRepo, 2*time.Second)
+
+	userSvc := service.NewUserService(userRepo)
+	userSvc.SetLogger(logger)
+	orderSvc := service.NewOrderService(orderRepo)
+	loyaltySvc := service.NewLoyaltyService(accrualAddr, m)
+	balanceSvc := service.NewBalanceService(userRepo)
+	balanceSvc.SetObserver(m)
+	accrualSync := service.NewAccrualSyncService(orderRepo, loyaltySvc)
+	accrualSync.SetLogger(logger)
+
+	orderHandler := handlers.NewOrderHandler(orderSvc, loyaltySvc)
+	userHandler := handlers.NewUserHandler(balanceSvc)
+
+	checks = append(checks, handlers.HealthCheck{Name: "accrual", Check: func(ctx context.Context) error {
+		return service.PingAccrualSystem(ctx, accrualAddr)
+	}})
+	healthHandler := handlers.NewHealthHandler(2*time.Second, checks...)
+	healthHandler.SetLogger(logger)
+
+	r := newRouter(logger, m, healthHandler)
+
+	r.POST("/api/user/register", handlers.RegisterHandler(userSvc, nil))
+	r.POST("/api/user/login", handlers.LoginHandler(userSvc, nil))
+
+	auth := r.Group("/api")
+	auth.Use(middleware.AuthMiddlewareJWT())
+	{
+		auth.POST("/user/orders", orderHandler.UploadOrderHandler)
+		auth.GET("/user/orders", orderHandler.GetOrdersHandler)
+
+		auth.GET("/user/balance", userHandler.GetBalance)
+		auth.POST("/user/balance/withdraw", userHandler.Withdraw)
+		auth.GET("/user/withdrawals", userHandler.GetWithdrawals)
+	}
+
+	for _, route := range unservedRoutes {
+		r.Any(route, notServed)
+		r.Any(route+"/*rest", notServed)
+	}
+
+	return &memberAPI{handler: r, health: healthHandler, sync: accrualSync}
+}
+
+func notServed(c *gin.Context) {
+	middleware.AbortWithError(c, middleware.NewAPIError(http.StatusNotImplemented, middleware.CodeNotImplemented,
+		"not available with this storage backend"))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Guldana11/gophermart/memory"
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberAPI_AccrualWorkerCreditsUploadedOrder(t *testing.T) {
+	middleware.SetJWTKey([]byte("test-secret"))
+
+	const number = "79927398713"
+	accrual := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/orders/"+number {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"order":"`+number+`","status":"PROCESSED","accrual":729.98}`)
+	}))
+	defer accrual.Close()
+
+	db := memory.New()
+	api := newMemberAPI(slog.New(slog.DiscardHandler), accrual.URL, memory.NewUserRepo(db), memory.NewOrderRepo(db))
+	srv := httptest.NewServer(api.handler)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/user/register", "application/json",
+		strings.NewReader(`{"login":"worker-e2e","password":"Str0ng-password"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	cookies := resp.Cookies()
+
+	do := func(method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "text/plain")
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp = do(http.MethodPost, "/api/user/orders", number)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	next, err := api.sync.SyncPending(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, next)
+
+	resp = do(http.MethodGet, "/api/user/balance", "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var balance models.BalanceResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&balance))
+	assert.InDelta(t, 729.98, balance.Current, 0.001)
+
+	resp = do(http.MethodGet, "/api/user/orders", "")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var orders []models.Order
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&orders))
+	require.Len(t, orders, 1)
+	assert.Equal(t, models.OrderStatusProcessed, orders[0].Status)
+	assert.InDelta(t, 729.98, orders[0].Accrual, 0.001)
+}
+
+func TestMemberAPI_AccrualWorkerScoresRequeuedOrder(t *testing.T) {
+	const number = "12345678903"
+	accrual := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"order":"`+number+`","status":"PROCESSED","accrual":40}`)
+	}))
+	defer accrual.Close()
+
+	ctx := context.Background()
+	db := memory.New()
+	users, orders := memory.NewUserRepo(db), memory.NewOrderRepo(db)
+	api := newMemberAPI(slog.New(slog.DiscardHandler), accrual.URL, users, orders)
+
+	user, err := users.CreateUser(ctx, "requeued", "secret")
+	require.NoError(t, err)
+	require.NoError(t, orders.CreateOrder(ctx, models.Order{UserID: user.ID, Number: number}))
+	require.NoError(t, orders.UpdateOrderAccrual(ctx, number, models.OrderStatusInvalid, 0, 0))
+
+	_, err = api.sync.Requeue(ctx, number, true)
+	require.NoError(t, err)
+	_, err = api.sync.SyncPending(ctx, "")
+	require.NoError(t, err)
+
+	order, err := orders.GetOrder(ctx, number)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessed, order.Status)
+	current, _, err := users.GetUserPoints(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 40.0, current)
+}
+
+func TestMemberAPI_UnservedRoutes(t *testing.T) {
+	db := memory.New()
+	api := newMemberAPI(slog.New(slog.DiscardHandler), "http://127.0.0.1:1", memory.NewUserRepo(db), memory.NewOrderRepo(db))
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{http.MethodPost, "/api/user/balance/holds", http.StatusNotImplemented},
+		{http.MethodPost, "/api/user/balance/holds/79927398713/capture", http.StatusNotImplemented},
+		{http.MethodGet, "/api/user/keys", http.StatusNotImplemented},
+		{http.MethodPost, "/api/user/vouchers/redeem", http.StatusNotImplemented},
+		{http.MethodPost, "/api/merchant/purchases", http.StatusNotImplemented},
+		{http.MethodGet, "/api/admin/users", http.StatusNotImplemented},
+		{http.MethodGet, "/api/user/balance", http.StatusUnauthorized},
+		{http.MethodGet, "/api/user/unknown", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			api.handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Guldana11/gophermart/database"
+	"github.com/Guldana11/gophermart/sqlite"
+)
+
+const migrateUsage = "usage: gophermart migrate up | down [N] | status | force <version>"
+
+// migrator is the migration API of a storage package; Postgres and SQLite
+// each embed their own migrations.
+type migrator struct {
+	up      func(dbURL string) error
+	down    func(dbURL string, steps int) error
+	force   func(dbURL string, version int) error
+	version func(dbURL string) (version uint, dirty bool, ok bool, err error)
+	latest  func() (uint, error)
+}
+
+func migratorFor(dbURL string) migrator {
+	if sqlite.IsURI(dbURL) {
+		return migrator{sqlite.Migrate, sqlite.MigrateDown, sqlite.ForceMigrationVersion, sqlite.MigrationVersion, sqlite.LatestMigrationVersion}
+	}
+	return migrator{database.Migrate, database.MigrateDown, database.ForceMigrationVersion, database.MigrationVersion, database.LatestMigrationVersion}
+}
+
+// runMigrate implements "gophermart migrate". Schema changes can then be
+// rolled out, and rolled back, separately from starting the server.
+func runMigrate(dbURL string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	m := migratorFor(dbURL)
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "up":
+		if len(rest) != 0 {
+			return errors.New(migrateUsage)
+		}
+		if err := m.up(dbURL); err != nil {
+			return err
+		}
+		return printMigrationStatus(m, dbURL, out)
+
+	case "down":
+		steps := 1
+		if len(rest) > 1 {
+			return errors.New(migrateUsage)
+		}
+		if len(rest) == 1 {
+			n, err := strconv.Atoi(rest[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", rest[0])
+			}
+			steps = n
+		}
+		if err := m.down(dbURL, steps); err != nil {
+			return err
+		}
+		return printMigrationStatus(m, dbURL, out)
+
+	case "status":
+		if len(rest) != 0 {
+			return errors.New(migrateUsage)
+		}
+		return printMigrationStatus(m, dbURL, out)
+
+	case "force":
+		if len(rest) != 1 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(rest[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		if err := m.force(dbURL, version); err != nil {
+			return err
+		}
+		return printMigrationStatus(m, dbURL, out)
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", cmd, migrateUsage)
+	}
+}
+
+func printMigrationStatus(m migrator, dbURL string, out io.Writer) error {
+	latest, err := m.latest()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, ok, err := m.version(dbURL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintf(out, "schema version: none, latest: %d\n", latest)
+		return nil
+	}
+
+	fmt.Fprintf(out, "schema version: %d, latest: %d", version, latest)
+	if dirty {
+		fmt.Fprint(out, " (dirty: fix the schema, then run migrate force)")
+	}
+	fmt.Fprintln(out)
+	return nil
+}
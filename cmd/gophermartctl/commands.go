@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/Guldana11/gophermart/database"
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/google/uuid"
+)
+
+func newFlagSet(name string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print what would change without changing anything")
+	return fs, dryRun
+}
+
+func runMigrate(ctx context.Context, a *app, args []string) error {
+	fs, dryRun := newFlagSet("migrate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	version, dirty, ok, err := database.MigrationVersion(a.dbURL)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Printf("current schema version: %d (dirty: %t)\n", version, dirty)
+	} else {
+		fmt.Println("current schema version: none")
+	}
+
+	if *dryRun {
+		fmt.Println("dry run: migrations not applied")
+		return nil
+	}
+	return database.Migrate(a.dbURL)
+}
+
+func runCreateAdmin(ctx context.Context, a *app, args []string) error {
+	fs, dryRun := newFlagSet("create-admin")
+	login := fs.String("login", "", "login of the new or existing user")
+	password := fs.String("password", "", "password, required when the user does not exist yet")
+	role := fs.String("role", models.RoleAdmin, "role to grant: admin or support")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *login == "" {
+		return errors.New("-login is required")
+	}
+	if *role != models.RoleAdmin && *role != models.RoleSupport {
+		return service.ErrInvalidRole
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	existing, err := a.users.GetUserByLogin(ctx, *login)
+	if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		return err
+	}
+
+	if existing != nil {
+		fmt.Printf("user %s (%s) exists with role %s; granting role %s\n", existing.Login, existing.ID, existing.Role, *role)
+		if *dryRun {
+			fmt.Println("dry run: role not changed")
+			return nil
+		}
+		err := a.adminSvc.SetRole(ctx, existing.ID, *role)
+		a.audit(ctx, "create-admin", existing.ID, map[string]any{"login": existing.Login, "role": *role, "previous_role": existing.Role}, err)
+		return err
+	}
+
+	if *password == "" {
+		return errors.New("-password is required to create a new user")
+	}
+
+	fmt.Printf("creating user %s with role %s\n", *login, *role)
+	if *dryRun {
+		fmt.Println("dry run: user not created")
+		return nil
+	}
+
+	created, err := a.users.CreateUser(ctx, *login, *password)
+	if err != nil {
+		a.audit(ctx, "create-admin", "", map[string]any{"login": *login, "role": *role}, err)
+		return err
+	}
+	err = a.adminSvc.SetRole(ctx, created.ID, *role)
+	a.audit(ctx, "create-admin", created.ID, map[string]any{"login": *login, "role": *role, "created": true}, err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created user %s\n", created.ID)
+	return nil
+}
+
+func runCreateMerchant(ctx context.Context, a *app, args []string) error {
+	fs, dryRun := newFlagSet("create-merchant")
+	name := fs.String("name", "", "store name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		return errors.New("-name is required")
+	}
+
+	fmt.Printf("creating merchant %q\n", *name)
+	if *dryRun {
+		fmt.Println("dry run: merchant not created")
+		return nil
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	merchant, token, err := a.merchantSvc.CreateMerchant(ctx, *name)
+	if err != nil {
+		a.audit(ctx, "create-merchant", "", map[string]any{"name": *name}, err)
+		return err
+	}
+	a.audit(ctx, "create-merchant", merchant.ID, map[string]any{"name": *name}, nil)
+
+	fmt.Printf("merchant id: %s\n", merchant.ID)
+	fmt.Printf("token (shown once): %s\n", token)
+	return nil
+}
+
+func runRequeueOrder(ctx context.Context, a *app, args []string) error {
+	fs, dryRun := newFlagSet("requeue-order")
+	number := fs.String("order", "", "order number")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *number == "" {
+		return errors.New("-order is required")
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	sync := service.NewAccrualSyncService(a.orders, nil)
+	sync.SetLogger(a.logger)
+	order, err := sync.Requeue(ctx, *number, !*dryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("order %s: %s -> %s\n", order.Number, order.Status, models.OrderStatusNew)
+	if *dryRun {
+		fmt.Println("dry run: order not changed")
+		return nil
+	}
+	fmt.Println("the server's accrual worker scores it on its next pass")
+	return nil
+}
+
+func runRescoreOrder(ctx context.Context, a *app, args []string) error {
+	fs, dryRun := newFlagSet("rescore-order")
+	number := fs.String("order", "", "order number")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *number == "" {
+		return errors.New("-order is required")
+	}
+
+	accrualAddr := os.Getenv("ACCRUAL_SYSTEM_ADDRESS")
+	if accrualAddr == "" {
+		return errors.New("ACCRUAL_SYSTEM_ADDRESS is not set")
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	sync := service.NewAccrualSyncService(a.orders, service.NewLoyaltyService(accrualAddr, nil))
+	sync.SetLogger(a.logger)
+	sync.SetCampaigns(a.campaignSvc)
+	sync.SetBonuses(a.accrualRuleSvc)
+	sync.SetReferrals(a.referralSvc)
+	sync.SetTxManager(a.tx)
+	res, err := sync.Rescore(ctx, *number, !*dryRun)
+	if !*dryRun {
+		var details map[string]any
+		if err == nil {
+			details = map[string]any{
+				"before": map[string]any{"status": res.Before.Status, "accrual": res.Before.Accrual, "bonus": res.Before.Bonus},
+				"after":  map[string]any{"status": res.Status, "accrual": res.Accrual, "bonus": res.Bonus},
+			}
+		}
+		a.audit(ctx, "rescore-order", *number, details, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("order %s: %s/%.2f+%.2f -> %s/%.2f+%.2f\n",
+		res.Before.Number, res.Before.Status, res.Before.Accrual, res.Before.Bonus, res.Status, res.Accrual, res.Bonus)
+	if *dryRun {
+		fmt.Println("dry run: order and balance not changed")
+	}
+	return nil
+}
+
+func runAdjustBalance(ctx context.Context, a *app, args []string) error {
+	fs, dryRun := newFlagSet("adjust-balance")
+	userRef := fs.String("user", "", "user ID or login")
+	amount := fs.Float64("amount", 0, "points to add; negative to deduct")
+	reason := fs.String("reason", "", "why the balance is adjusted (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *amount == 0 || strings.TrimSpace(*reason) == "" {
+		return service.ErrInvalidAdjustment
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	u, err := a.resolveUser(ctx, *userRef)
+	if err != nil {
+		return err
+	}
+
+	current, _, err := a.users.GetUserPoints(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("user %s (%s): balance %.2f -> %.2f, reason: %s\n", u.Login, u.ID, current, current+*amount, *reason)
+	if *dryRun {
+		fmt.Println("dry run: balance not changed")
+		return nil
+	}
+
+	adj, err := a.adminSvc.AdjustBalance(ctx, cliActor(), u.ID, *amount, *reason)
+	details := map[string]any{"amount": *amount, "reason": *reason}
+	if err == nil {
+		details["adjustment_id"] = adj.ID
+	}
+	a.audit(ctx, "adjust-balance", u.ID, details, err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("adjustment %d recorded\n", adj.ID)
+	return nil
+}
+
+type accountDump struct {
+	User        models.User                `json:"user"`
+	Balance     models.BalanceResponse     `json:"balance"`
+	Orders      []models.Order             `json:"orders"`
+	Withdrawals []models.Withdrawal        `json:"withdrawals"`
+	Holds       []models.PointHold         `json:"holds"`
+	Adjustments []models.BalanceAdjustment `json:"adjustments"`
+	APIKeys     []models.APIKey            `json:"api_keys"`
+}
+
+func runDumpUser(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("dump-user", flag.ContinueOnError)
+	userRef := fs.String("user", "", "user ID or login")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	u, err := a.resolveUser(ctx, *userRef)
+	if err != nil {
+		return err
+	}
+
+	dump := accountDump{User: *u}
+
+	if dump.Balance.Current, dump.Balance.Withdrawn, err = a.users.GetUserPoints(ctx, u.ID); err != nil {
+		return err
+	}
+	if dump.Balance.Held, err = a.holds.GetHeldPoints(ctx, u.ID); err != nil {
+		return err
+	}
+	if dump.Orders, err = a.orders.GetOrdersByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	if dump.Withdrawals, err = a.users.GetUserWithdrawals(ctx, u.ID); err != nil {
+		return err
+	}
+	if dump.Holds, err = a.holds.GetUserHolds(ctx, u.ID); err != nil {
+		return err
+	}
+	if dump.Adjustments, err = a.admin.GetAdjustments(ctx, u.ID); err != nil {
+		return err
+	}
+	if dump.APIKeys, err = a.apiKeys.ListAPIKeys(ctx, u.ID); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dump)
+}
+
+// resolveUser accepts either a user ID or a login.
+func (a *app) resolveUser(ctx context.Context, ref string) (*models.User, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("-user is required")
+	}
+
+	if uuid.Validate(ref) == nil {
+		return a.admin.GetUserByID(ctx, ref)
+	}
+
+	return a.users.GetUserByLogin(ctx, ref)
+}
+
+// audit records a command that changed state on the audit trail, as the admin
+// API does for its own calls. The outcome follows err, whose envelope code
+// becomes the failure reason.
+func (a *app) audit(ctx context.Context, command, subject string, details map[string]any, err error) {
+	event := models.AuditEvent{
+		Action:    models.AuditActionCLIPrefix + command,
+		Outcome:   models.AuditOutcomeSuccess,
+		Actor:     cliActor(),
+		Subject:   subject,
+		Details:   details,
+		UserAgent: "gophermartctl",
+	}
+	if err != nil {
+		event.Outcome = models.AuditOutcomeFailure
+		event.Reason = middleware.ToAPIError(err).Code
+	}
+	a.auditSvc.Record(ctx, event)
+}
+
+func cliActor() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return "cli:" + name
+}
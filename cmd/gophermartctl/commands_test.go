@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditLog []models.AuditEvent
+
+func (l *auditLog) AppendAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	*l = append(*l, event)
+	return nil
+}
+
+func (l *auditLog) ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	return *l, nil
+}
+
+func (l *auditLog) PurgeAuditEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestAppAudit(t *testing.T) {
+	var events auditLog
+	a := &app{auditSvc: service.NewAuditService(&events, 0)}
+
+	a.audit(context.Background(), "adjust-balance", "user-1", map[string]any{"amount": 5.0}, nil)
+	a.audit(context.Background(), "create-merchant", "", nil, service.ErrMerchantNameRequired)
+
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "cli.adjust-balance", events[0].Action)
+	assert.Equal(t, models.AuditOutcomeSuccess, events[0].Outcome)
+	assert.True(t, strings.HasPrefix(events[0].Actor, "cli:"))
+	assert.Equal(t, "user-1", events[0].Subject)
+	assert.False(t, events[0].OccurredAt.IsZero())
+
+	assert.Equal(t, "cli.create-merchant", events[1].Action)
+	assert.Equal(t, models.AuditOutcomeFailure, events[1].Outcome)
+	assert.NotEmpty(t, events[1].Reason)
+}
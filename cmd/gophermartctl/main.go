@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+
+	"github.com/Guldana11/gophermart/database"
+	"github.com/Guldana11/gophermart/logging"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+}
+
+var commands = map[string]command{
+	"migrate":         {"migrate [-dry-run]", runMigrate},
+	"create-admin":    {"create-admin -login <login> [-password <password>] [-role admin|support] [-dry-run]", runCreateAdmin},
+	"create-merchant": {"create-merchant -name <name> [-dry-run]", runCreateMerchant},
+	"requeue-order":   {"requeue-order -order <number> [-dry-run]", runRequeueOrder},
+	"rescore-order":   {"rescore-order -order <number> [-dry-run]", runRescoreOrder},
+	"adjust-balance":  {"adjust-balance -user <id|login> -amount <points> -reason <text> [-dry-run]", runAdjustBalance},
+	"dump-user":       {"dump-user -user <id|login>", runDumpUser},
+}
+
+// app holds the repositories and services shared by the commands. The pool is
+// opened lazily so that "migrate" can run against an empty database.
+type app struct {
+	dbURL  string
+	logger *slog.Logger
+	pool   *pgxpool.Pool
+	tx     *database.TxManager
+
+	users     *database.UserRepo
+	orders    *database.OrderRepo
+	holds     *database.HoldRepo
+	merchants *database.MerchantRepo
+	apiKeys   *database.APIKeyRepo
+	admin     *database.AdminRepo
+	campaigns *database.CampaignRepo
+	rules     *database.AccrualRuleRepo
+
+	adminSvc    *service.AdminService
+	auditSvc    *service.AuditService
+	merchantSvc *service.MerchantService
+	campaignSvc *service.CampaignService
+	referralSvc *service.ReferralService
+
+	accrualRuleSvc *service.AccrualRuleService
+}
+
+func (a *app) connect() error {
+	if a.pool != nil {
+		return nil
+	}
+
+	poolCfg, err := database.PoolConfigFromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	pool, err := database.Connect(context.Background(), a.dbURL, poolCfg, a.logger)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+
+	a.pool = pool
+	a.tx = database.NewTxManager(pool)
+	a.tx.SetLogger(a.logger)
+	a.users = database.NewUserRepo(pool)
+	a.orders = database.NewOrderRepo(pool)
+	a.holds = database.NewHoldRepo(pool)
+	a.merchants = database.NewMerchantRepo(pool)
+	a.apiKeys = database.NewAPIKeyRepo(pool)
+	a.admin = database.NewAdminRepo(pool)
+	a.campaigns = database.NewCampaignRepo(pool)
+	a.rules = database.NewAccrualRuleRepo(pool)
+
+	a.adminSvc = service.NewAdminService(a.admin, a.users, a.orders)
+	a.auditSvc = service.NewAuditService(database.NewAuditRepo(pool), 0)
+	a.auditSvc.SetLogger(a.logger)
+	a.merchantSvc = service.NewMerchantService(a.merchants, service.NewOrderService(a.orders))
+	a.campaignSvc = service.NewCampaignService(a.campaigns, a.orders)
+
+	referralCfg, err := service.ReferralConfigFromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	a.referralSvc = service.NewReferralService(database.NewReferralRepo(pool), referralCfg)
+	a.accrualRuleSvc = service.NewAccrualRuleService(a.rules)
+	return nil
+}
+
+func (a *app) close() {
+	if a.pool != nil {
+		a.pool.Close()
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	dbURL := os.Getenv("DATABASE_URI")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URI is not set")
+	}
+
+	logger, err := logging.FromEnv(os.Stderr, os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	a := &app{dbURL: dbURL, logger: logger}
+	defer a.close()
+
+	if err := cmd.run(context.Background(), a, os.Args[2:]); err != nil {
+		a.close()
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: gophermartctl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "DATABASE_URI must be set; rescore-order also needs ACCRUAL_SYSTEM_ADDRESS.")
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.AccrualRuleRepository = (*AccrualRuleRepo)(nil)
+
+const accrualRuleColumns = `id, name, multiplier, bonus, min_accrual, max_bonus, stackable, starts_at, ends_at, created_at, disabled_at`
+
+type AccrualRuleRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAccrualRuleRepo(db *pgxpool.Pool) *AccrualRuleRepo {
+	return &AccrualRuleRepo{db: db}
+}
+
+func (r *AccrualRuleRepo) CreateRule(ctx context.Context, rule models.AccrualRule) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO accrual_rules (id, name, multiplier, bonus, min_accrual, max_bonus, stackable, starts_at, ends_at, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rule.ID, rule.Name, rule.Multiplier, rule.Bonus, rule.MinAccrual, rule.MaxBonus,
+		rule.Stackable, rule.StartsAt, rule.EndsAt, rule.CreatedAt,
+	)
+	return translateError(err)
+}
+
+func (r *AccrualRuleRepo) ListRules(ctx context.Context) ([]models.AccrualRule, error) {
+	return r.queryRules(ctx,
+		`SELECT `+accrualRuleColumns+` FROM accrual_rules ORDER BY created_at DESC`)
+}
+
+func (r *AccrualRuleRepo) DisableRule(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE accrual_rules SET disabled_at = COALESCE(disabled_at, NOW()) WHERE id = $1`, id)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrAccrualRuleNotFound
+	}
+	return nil
+}
+
+func (r *AccrualRuleRepo) RulesAt(ctx context.Context, at time.Time) ([]models.AccrualRule, error) {
+	return r.queryRules(ctx,
+		`SELECT `+accrualRuleColumns+`
+         FROM accrual_rules
+         WHERE disabled_at IS NULL
+           AND starts_at <= $1
+           AND (ends_at IS NULL OR ends_at > $1)
+         ORDER BY starts_at, id`,
+		at,
+	)
+}
+
+func (r *AccrualRuleRepo) queryRules(ctx context.Context, query string, args ...any) ([]models.AccrualRule, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	rules := make([]models.AccrualRule, 0)
+	for rows.Next() {
+		var rule models.AccrualRule
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Multiplier, &rule.Bonus, &rule.MinAccrual,
+			&rule.MaxBonus, &rule.Stackable, &rule.StartsAt, &rule.EndsAt, &rule.CreatedAt, &rule.DisabledAt); err != nil {
+			return nil, translateError(err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return rules, nil
+}
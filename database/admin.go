@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.AdminRepository = (*AdminRepo)(nil)
+
+type AdminRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAdminRepo(db *pgxpool.Pool) *AdminRepo {
+	return &AdminRepo{db: db}
+}
+
+func (r *AdminRepo) SearchUsers(ctx context.Context, login string, limit int) ([]models.User, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+userColumns+`
+         FROM users
+         WHERE login ILIKE '%' || $1 || '%'
+         ORDER BY login
+         LIMIT $2`,
+		login, limit,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var u models.User
+		if err := scanUser(rows, &u); err != nil {
+			return nil, translateError(err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return users, nil
+}
+
+func (r *AdminRepo) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	var u models.User
+	err := scanUser(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id = $1`, userID), &u)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrUserNotFound
+		}
+		return nil, translateError(err)
+	}
+	return &u, nil
+}
+
+func (r *AdminRepo) SetUserBlocked(ctx context.Context, userID string, blocked bool) error {
+	query := `UPDATE users SET blocked_at = COALESCE(blocked_at, NOW()) WHERE id = $1`
+	if !blocked {
+		query = `UPDATE users SET blocked_at = NULL WHERE id = $1`
+	}
+
+	res, err := conn(ctx, r.db).Exec(ctx, query, userID)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *AdminRepo) SetUserRole(ctx context.Context, userID, role string) error {
+	res, err := conn(ctx, r.db).Exec(ctx, `UPDATE users SET role = $1 WHERE id = $2`, role, userID)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *AdminRepo) AdjustBalance(ctx context.Context, adj models.BalanceAdjustment) (*models.BalanceAdjustment, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, adj.UserID).Scan(&exists)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if !exists {
+		return nil, service.ErrUserNotFound
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
+         VALUES ($1, 0, 0)
+         ON CONFLICT (user_id) DO NOTHING`,
+		adj.UserID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	var current float64
+	err = tx.QueryRow(ctx,
+		`SELECT current_balance FROM user_points WHERE user_id = $1 FOR UPDATE`,
+		adj.UserID,
+	).Scan(&current)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if current+adj.Amount < 0 {
+		return nil, service.ErrInsufficientFunds
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE user_points
+         SET current_balance = current_balance + $1,
+             updated_at = NOW()
+         WHERE user_id = $2`,
+		adj.Amount, adj.UserID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO balance_adjustments (user_id, amount, reason, actor)
+         VALUES ($1, $2, $3, $4)
+         RETURNING id, created_at`,
+		adj.UserID, adj.Amount, adj.Reason, adj.Actor,
+	).Scan(&adj.ID, &adj.CreatedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	err = appendEvent(ctx, tx, models.EventPointsCredited, adj.UserID, models.PointsCreditedData{
+		Amount: adj.Amount, Source: models.CreditSourceAdjustment, Reference: strconv.FormatInt(adj.ID, 10),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &adj, translateError(tx.Commit(ctx))
+}
+
+func (r *AdminRepo) GetAdjustments(ctx context.Context, userID string) ([]models.BalanceAdjustment, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, user_id, amount, reason, actor, created_at
+         FROM balance_adjustments
+         WHERE user_id = $1
+         ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	adjustments := make([]models.BalanceAdjustment, 0)
+	for rows.Next() {
+		var a models.BalanceAdjustment
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Amount, &a.Reason, &a.Actor, &a.CreatedAt); err != nil {
+			return nil, translateError(err)
+		}
+		adjustments = append(adjustments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return adjustments, nil
+}
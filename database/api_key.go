@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.APIKeyRepository = (*APIKeyRepo)(nil)
+
+type APIKeyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepo(db *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO api_keys (id, name, prefix, key_hash, scopes, user_id, merchant_id, created_at)
+         VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::UUID, NULLIF($7, '')::UUID, $8)`,
+		key.ID, key.Name, key.Prefix, keyHash, key.Scopes, key.UserID, key.MerchantID, key.CreatedAt,
+	)
+	return translateError(err)
+}
+
+func (r *APIKeyRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var k models.APIKey
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, name, prefix, scopes, COALESCE(user_id::TEXT, ''), COALESCE(merchant_id::TEXT, ''),
+                created_at, last_used_at
+         FROM api_keys
+         WHERE key_hash = $1 AND revoked_at IS NULL`,
+		keyHash,
+	).Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.UserID, &k.MerchantID, &k.CreatedAt, &k.LastUsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrInvalidAPIKey
+		}
+		return nil, translateError(err)
+	}
+	return &k, nil
+}
+
+// TouchAPIKey records usage at most once a minute per key, so busy
+// integrations do not turn every request into a write.
+func (r *APIKeyRepo) TouchAPIKey(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE api_keys
+         SET last_used_at = NOW()
+         WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`,
+		id,
+	)
+	return translateError(err)
+}
+
+func (r *APIKeyRepo) ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, name, prefix, scopes, created_at, last_used_at, revoked_at
+         FROM api_keys
+         WHERE user_id = $1
+         ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		k := models.APIKey{UserID: userID}
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, translateError(err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE api_keys
+         SET revoked_at = NOW()
+         WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrAPIKeyNotFound
+	}
+	return nil
+}
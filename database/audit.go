@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.AuditRepository = (*AuditRepo)(nil)
+
+type AuditRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepo(db *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+func (r *AuditRepo) AppendAuditEvent(ctx context.Context, e models.AuditEvent) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+	if e.Details == nil {
+		details = []byte("{}")
+	}
+
+	_, err = conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO audit_events (occurred_at, action, outcome, actor, subject, reason, details, ip, user_agent, request_id)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		e.OccurredAt, e.Action, e.Outcome, e.Actor, e.Subject, e.Reason, details, e.IP, e.UserAgent, e.RequestID,
+	)
+	return translateError(err)
+}
+
+func (r *AuditRepo) ListAuditEvents(ctx context.Context, f models.AuditFilter) ([]models.AuditEvent, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.Actor != "" {
+		add("actor = $%d", f.Actor)
+	}
+	if f.Subject != "" {
+		add("subject = $%d", f.Subject)
+	}
+	if f.Outcome != "" {
+		add("outcome = $%d", f.Outcome)
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	if f.BeforeID > 0 {
+		add("id < $%d", f.BeforeID)
+	}
+
+	query := `SELECT id, occurred_at, action, outcome, actor, subject, reason, details, ip, user_agent, request_id
+              FROM audit_events`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		var e models.AuditEvent
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.Action, &e.Outcome, &e.Actor, &e.Subject,
+			&e.Reason, &details, &e.IP, &e.UserAgent, &e.RequestID); err != nil {
+			return nil, translateError(err)
+		}
+		if err := json.Unmarshal(details, &e.Details); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return events, nil
+}
+
+func (r *AuditRepo) PurgeAuditEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT set_config('gophermart.audit_purge', 'on', true)"); err != nil {
+		return 0, translateError(err)
+	}
+
+	tag, err := tx.Exec(ctx, "DELETE FROM audit_events WHERE occurred_at < $1", cutoff)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return tag.RowsAffected(), translateError(tx.Commit(ctx))
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.CampaignRepository = (*CampaignRepo)(nil)
+
+const campaignColumns = `id, name, kind, amount, starts_at, ends_at, per_user_cap, repeat_every_seconds, created_at, disabled_at`
+
+type CampaignRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewCampaignRepo(db *pgxpool.Pool) *CampaignRepo {
+	return &CampaignRepo{db: db}
+}
+
+func (r *CampaignRepo) CreateCampaign(ctx context.Context, c models.Campaign) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO campaigns (id, name, kind, amount, starts_at, ends_at, per_user_cap, repeat_every_seconds, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		c.ID, c.Name, c.Kind, c.Amount, c.StartsAt, c.EndsAt, c.PerUserCap, c.RepeatEverySeconds, c.CreatedAt,
+	)
+	return translateError(err)
+}
+
+func (r *CampaignRepo) ListCampaigns(ctx context.Context) ([]models.Campaign, error) {
+	return r.queryCampaigns(ctx,
+		`SELECT `+campaignColumns+` FROM campaigns ORDER BY created_at DESC`)
+}
+
+func (r *CampaignRepo) DisableCampaign(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE campaigns SET disabled_at = COALESCE(disabled_at, NOW()) WHERE id = $1`, id)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrCampaignNotFound
+	}
+	return nil
+}
+
+func (r *CampaignRepo) ActiveCampaigns(ctx context.Context, kind string, at time.Time) ([]models.Campaign, error) {
+	return r.queryCampaigns(ctx,
+		`SELECT `+campaignColumns+`
+         FROM campaigns
+         WHERE kind = $1
+           AND disabled_at IS NULL
+           AND starts_at <= $2
+           AND (ends_at IS NULL OR ends_at > $2)
+         ORDER BY starts_at, id`,
+		kind, at,
+	)
+}
+
+func (r *CampaignRepo) queryCampaigns(ctx context.Context, query string, args ...any) ([]models.Campaign, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	campaigns := make([]models.Campaign, 0)
+	for rows.Next() {
+		var c models.Campaign
+		if err := rows.Scan(&c.ID, &c.Name, &c.Kind, &c.Amount, &c.StartsAt, &c.EndsAt,
+			&c.PerUserCap, &c.RepeatEverySeconds, &c.CreatedAt, &c.DisabledAt); err != nil {
+			return nil, translateError(err)
+		}
+		campaigns = append(campaigns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return campaigns, nil
+}
+
+// GrantCampaign locks the member's points row first, so concurrent grants for
+// the same member are serialized and cannot both pass the check for an
+// earlier grant.
+func (r *CampaignRepo) GrantCampaign(ctx context.Context, grant models.CampaignGrant, campaign models.Campaign) (bool, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return false, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
+         VALUES ($1, 0, 0)
+         ON CONFLICT (user_id) DO NOTHING`,
+		grant.UserID,
+	)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	_, err = tx.Exec(ctx, `SELECT 1 FROM user_points WHERE user_id = $1 FOR UPDATE`, grant.UserID)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	var granted int
+	var last *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT COUNT(*), MAX(created_at)
+         FROM campaign_grants
+         WHERE campaign_id = $1 AND user_id = $2`,
+		grant.CampaignID, grant.UserID,
+	).Scan(&granted, &last)
+	if err != nil {
+		return false, translateError(err)
+	}
+	if !service.GrantDue(campaign, granted, last, grant.CreatedAt) {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO campaign_grants (campaign_id, user_id, amount, reference, created_at)
+         VALUES ($1, $2, $3, $4, $5)`,
+		grant.CampaignID, grant.UserID, grant.Amount, grant.Reference, grant.CreatedAt,
+	)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE user_points
+         SET current_balance = current_balance + $1,
+             updated_at = NOW()
+         WHERE user_id = $2`,
+		grant.Amount, grant.UserID,
+	)
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	err = appendEvent(ctx, tx, models.EventPointsCredited, grant.UserID, models.PointsCreditedData{
+		Amount: grant.Amount, Source: models.CreditSourceCampaign, Reference: grant.CampaignID,
+	})
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, translateError(err)
+	}
+	return true, nil
+}
+
+func (r *CampaignRepo) GetUserGrants(ctx context.Context, userID string) ([]models.CampaignGrant, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, campaign_id, user_id, amount, reference, created_at
+         FROM campaign_grants
+         WHERE user_id = $1
+         ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	grants := make([]models.CampaignGrant, 0)
+	for rows.Next() {
+		var g models.CampaignGrant
+		if err := rows.Scan(&g.ID, &g.CampaignID, &g.UserID, &g.Amount, &g.Reference, &g.CreatedAt); err != nil {
+			return nil, translateError(err)
+		}
+		grants = append(grants, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return grants, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres SQLSTATE codes the repositories translate.
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgCheckViolation       = "23514"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// constraintErrors names the domain error a violation of a given constraint
+// stands for, so callers get e.g. ErrLoginTaken instead of a bare conflict.
+var constraintErrors = map[string]error{
+	"users_login_key":   service.ErrLoginTaken,
+	"withdrawals_pkey":  service.ErrInvalidOrder,
+	"point_holds_pkey":  service.ErrInvalidOrder,
+	"orders_number_key": service.ErrAlreadyUploadedOther,
+}
+
+// translateError maps driver errors onto the service error taxonomy. The
+// original error stays in the chain for logging; errors it does not know are
+// returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", service.ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	if target, ok := constraintErrors[pgErr.ConstraintName]; ok {
+		return fmt.Errorf("%w: %w", target, err)
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return fmt.Errorf("%w: %w", service.ErrConflict, err)
+	case pgForeignKeyViolation:
+		return fmt.Errorf("%w: %w", service.ErrInvalidReference, err)
+	case pgCheckViolation:
+		return service.Wrap(service.KindValidation, "value rejected by a check constraint", err)
+	case pgSerializationFailure, pgDeadlockDetected:
+		return fmt.Errorf("%w: %w", service.ErrSerialization, err)
+	}
+	return err
+}
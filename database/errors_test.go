@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	plain := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+		kind     service.Kind
+	}{
+		{"nil", nil, nil, service.KindInternal},
+		{"no rows", pgx.ErrNoRows, service.ErrNotFound, service.KindNotFound},
+		{
+			name:     "login unique violation",
+			err:      &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_login_key"},
+			expected: service.ErrLoginTaken,
+			kind:     service.KindConflict,
+		},
+		{
+			name:     "other unique violation",
+			err:      &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "merchants_token_hash_key"},
+			expected: service.ErrConflict,
+			kind:     service.KindConflict,
+		},
+		{
+			name:     "foreign key violation",
+			err:      &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: "fk_user_points_user"},
+			expected: service.ErrInvalidReference,
+			kind:     service.KindValidation,
+		},
+		{
+			name:     "serialization failure",
+			err:      &pgconn.PgError{Code: pgSerializationFailure},
+			expected: service.ErrSerialization,
+			kind:     service.KindConflict,
+		},
+		{
+			name: "check violation",
+			err:  &pgconn.PgError{Code: pgCheckViolation, ConstraintName: "chk_number_digits"},
+			kind: service.KindValidation,
+		},
+		{"unknown error", plain, plain, service.KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if tt.err == nil {
+				assert.NoError(t, got)
+				return
+			}
+
+			if tt.expected != nil {
+				assert.ErrorIs(t, got, tt.expected)
+			}
+			assert.ErrorIs(t, got, tt.err, "driver error must stay in the chain")
+			assert.Equal(t, tt.kind, service.KindOf(got))
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.HoldRepository = (*HoldRepo)(nil)
+
+type HoldRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewHoldRepo(db *pgxpool.Pool) *HoldRepo {
+	return &HoldRepo{db: db}
+}
+
+func (r *HoldRepo) AuthorizeHold(ctx context.Context, hold models.PointHold) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOrderNumber(ctx, tx, hold.OrderNumber); err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM withdrawals WHERE order_number=$1)
+             OR EXISTS (SELECT 1 FROM point_holds WHERE order_number=$1)`,
+		hold.OrderNumber,
+	).Scan(&exists)
+	if err != nil {
+		return translateError(err)
+	}
+	if exists {
+		return service.ErrInvalidOrder
+	}
+
+	var current float64
+	err = tx.QueryRow(ctx,
+		`SELECT current_balance
+         FROM user_points
+         WHERE user_id = $1
+         FOR UPDATE`,
+		hold.UserID,
+	).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.ErrInsufficientFunds
+		}
+		return translateError(err)
+	}
+
+	if hold.Sum > current {
+		return service.ErrInsufficientFunds
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE user_points
+         SET current_balance = current_balance - $1,
+             held_points = held_points + $1,
+             updated_at = NOW()
+         WHERE user_id = $2`,
+		hold.Sum, hold.UserID,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO point_holds (order_number, user_id, sum, status, created_at, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6)`,
+		hold.OrderNumber, hold.UserID, hold.Sum, models.HoldStatusAuthorized, hold.CreatedAt, hold.ExpiresAt,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit(ctx))
+}
+
+func (r *HoldRepo) CaptureHold(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	hold, err := lockActiveHold(ctx, tx, userID, order)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE user_points
+         SET held_points = held_points - $1,
+             withdrawn_points = withdrawn_points + $1,
+             updated_at = NOW()
+         WHERE user_id = $2`,
+		hold.Sum, userID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO withdrawals (user_id, order_number, sum, processed_at)
+         VALUES ($1, $2, $3, NOW())`,
+		userID, order, hold.Sum,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	err = appendEvent(ctx, tx, models.EventPointsWithdrawn, userID, models.PointsWithdrawnData{Order: order, Sum: hold.Sum})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := setHoldStatus(ctx, tx, order, models.HoldStatusCaptured); err != nil {
+		return nil, translateError(err)
+	}
+	hold.Status = models.HoldStatusCaptured
+
+	return hold, translateError(tx.Commit(ctx))
+}
+
+func (r *HoldRepo) VoidHold(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	hold, err := lockActiveHold(ctx, tx, userID, order)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := releaseHeldPoints(ctx, tx, userID, hold.Sum); err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := setHoldStatus(ctx, tx, order, models.HoldStatusVoided); err != nil {
+		return nil, translateError(err)
+	}
+	hold.Status = models.HoldStatusVoided
+
+	return hold, translateError(tx.Commit(ctx))
+}
+
+func (r *HoldRepo) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE point_holds
+         SET status = $1, updated_at = NOW()
+         WHERE status = $2 AND expires_at <= $3
+         RETURNING user_id, sum`,
+		models.HoldStatusExpired, models.HoldStatusAuthorized, now,
+	)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	released := make(map[string]float64)
+	count := 0
+	for rows.Next() {
+		var userID string
+		var sum float64
+		if err := rows.Scan(&userID, &sum); err != nil {
+			rows.Close()
+			return 0, translateError(err)
+		}
+		released[userID] += sum
+		count++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, translateError(err)
+	}
+
+	for userID, sum := range released {
+		if err := releaseHeldPoints(ctx, tx, userID, sum); err != nil {
+			return 0, translateError(err)
+		}
+	}
+
+	return count, translateError(tx.Commit(ctx))
+}
+
+func (r *HoldRepo) GetHeldPoints(ctx context.Context, userID string) (float64, error) {
+	var held float64
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT held_points FROM user_points WHERE user_id = $1`, userID).
+		Scan(&held)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, translateError(err)
+	}
+	return held, nil
+}
+
+func (r *HoldRepo) GetUserHolds(ctx context.Context, userID string) ([]models.PointHold, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT order_number, user_id, sum, status, created_at, expires_at
+         FROM point_holds
+         WHERE user_id = $1
+         ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	holds := make([]models.PointHold, 0)
+	for rows.Next() {
+		var h models.PointHold
+		if err := rows.Scan(&h.OrderNumber, &h.UserID, &h.Sum, &h.Status, &h.CreatedAt, &h.ExpiresAt); err != nil {
+			return nil, translateError(err)
+		}
+		holds = append(holds, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return holds, nil
+}
+
+// lockActiveHold loads a hold owned by userID and locks it for the rest of the
+// transaction. Holds that were already settled or have passed their expiry are
+// reported as ErrHoldNotActive; the sweeper releases the latter.
+func lockActiveHold(ctx context.Context, tx pgx.Tx, userID, order string) (*models.PointHold, error) {
+	var h models.PointHold
+	err := tx.QueryRow(ctx,
+		`SELECT order_number, user_id, sum, status, created_at, expires_at
+         FROM point_holds
+         WHERE order_number = $1 AND user_id = $2
+         FOR UPDATE`,
+		order, userID,
+	).Scan(&h.OrderNumber, &h.UserID, &h.Sum, &h.Status, &h.CreatedAt, &h.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrHoldNotFound
+		}
+		return nil, translateError(err)
+	}
+
+	if h.Status != models.HoldStatusAuthorized || !h.ExpiresAt.After(time.Now()) {
+		return nil, service.ErrHoldNotActive
+	}
+
+	return &h, nil
+}
+
+func releaseHeldPoints(ctx context.Context, tx pgx.Tx, userID string, sum float64) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE user_points
+         SET held_points = held_points - $1,
+             current_balance = current_balance + $1,
+             updated_at = NOW()
+         WHERE user_id = $2`,
+		sum, userID,
+	)
+	return translateError(err)
+}
+
+func setHoldStatus(ctx context.Context, tx pgx.Tx, order, status string) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE point_holds SET status = $1, updated_at = NOW() WHERE order_number = $2`,
+		status, order,
+	)
+	return translateError(err)
+}
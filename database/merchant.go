@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.MerchantRepository = (*MerchantRepo)(nil)
+
+type MerchantRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewMerchantRepo(db *pgxpool.Pool) *MerchantRepo {
+	return &MerchantRepo{db: db}
+}
+
+func (r *MerchantRepo) CreateMerchant(ctx context.Context, merchant models.Merchant, tokenHash string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		"INSERT INTO merchants (id, name, token_hash, created_at) VALUES ($1, $2, $3, $4)",
+		merchant.ID, merchant.Name, tokenHash, merchant.CreatedAt,
+	)
+	return translateError(err)
+}
+
+func (r *MerchantRepo) GetMerchantByTokenHash(ctx context.Context, tokenHash string) (*models.Merchant, error) {
+	var m models.Merchant
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, name, created_at
+         FROM merchants
+         WHERE token_hash = $1 AND revoked_at IS NULL`,
+		tokenHash,
+	).Scan(&m.ID, &m.Name, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrInvalidMerchantToken
+		}
+		return nil, translateError(err)
+	}
+	return &m, nil
+}
+
+func (r *MerchantRepo) FindMember(ctx context.Context, login, card string) (*models.User, error) {
+	query := "SELECT id, login, COALESCE(loyalty_card, ''), created_at FROM users WHERE login = $1"
+	arg := login
+	if card != "" {
+		query = "SELECT id, login, COALESCE(loyalty_card, ''), created_at FROM users WHERE loyalty_card = $1"
+		arg = card
+	}
+
+	var u models.User
+	err := conn(ctx, r.db).QueryRow(ctx, query, arg).Scan(&u.ID, &u.Login, &u.LoyaltyCard, &u.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrMemberNotFound
+		}
+		return nil, translateError(err)
+	}
+	return &u, nil
+}
+
+func (r *MerchantRepo) SavePurchase(ctx context.Context, purchase models.MerchantPurchase) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO merchant_purchases (order_number, merchant_id, user_id, amount, created_at)
+         VALUES ($1, $2, $3, $4, $5)
+         ON CONFLICT (order_number) DO NOTHING`,
+		purchase.OrderNumber, purchase.MerchantID, purchase.UserID, purchase.Amount, purchase.CreatedAt,
+	)
+	return translateError(err)
+}
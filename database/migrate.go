@@ -1,40 +1,151 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 
+	"github.com/Guldana11/gophermart/migrations"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// Migrate applies every pending migration.
 func Migrate(dbURL string) error {
-	sqlDB, err := sql.Open("pgx", dbURL)
+	m, closeDB, err := newMigrate(dbURL)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return err
 	}
-	defer sqlDB.Close()
+	defer closeDB()
 
-	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migrate driver: %w", err)
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	return nil
+}
+
+// MigrateDown reverts the last steps migrations.
+func MigrateDown(dbURL string, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres",
-		driver,
-	)
+	m, closeDB, err := newMigrate(dbURL)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return err
 	}
+	defer closeDB()
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	if err := m.Steps(-steps); err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
-
-	fmt.Println("Migrations applied successfully")
 	return nil
 }
+
+// ForceMigrationVersion records version as applied and clears the dirty flag
+// without running any SQL. It is the way out after a migration failed
+// halfway and the schema was repaired by hand; -1 marks no migration applied.
+func ForceMigrationVersion(dbURL string, version int) error {
+	m, closeDB, err := newMigrate(dbURL)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	return m.Force(version)
+}
+
+// MigrationVersion reports the schema version currently applied. ok is false
+// when no migration has been run yet.
+func MigrationVersion(dbURL string) (version uint, dirty bool, ok bool, err error) {
+	m, closeDB, err := newMigrate(dbURL)
+	if err != nil {
+		return 0, false, false, err
+	}
+	defer closeDB()
+
+	version, dirty, err = m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, err
+	}
+	return version, dirty, true, nil
+}
+
+// LatestMigrationVersion returns the newest migration built into the binary.
+func LatestMigrationVersion() (uint, error) {
+	src, err := migrationSource()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("no migrations embedded: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// SchemaVersion reads the applied schema version through the pool, which is
+// cheap enough for readiness probes. MigrationVersion opens a connection of
+// its own.
+func SchemaVersion(ctx context.Context, db *pgxpool.Pool) (version uint, dirty bool, err error) {
+	var v int64
+	err = db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&v, &dirty)
+	if err != nil {
+		return 0, false, translateError(err)
+	}
+	return uint(v), dirty, nil
+}
+
+func migrationSource() (source.Driver, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	return src, nil
+}
+
+func newMigrate(dbURL string) (*migrate.Migrate, func(), error) {
+	src, err := migrationSource()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sqlDB, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	driver, err := postgres.WithInstance(sqlDB, &postgres.Config{})
+	if err != nil {
+		sqlDB.Close()
+		return nil, nil, fmt.Errorf("failed to create migrate driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		sqlDB.Close()
+		return nil, nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return m, func() { m.Close() }, nil
+}
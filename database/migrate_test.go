@@ -0,0 +1,77 @@
+package database
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Guldana11/gophermart/migrations"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	ups, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	downs, err := fs.Glob(migrations.FS, "*.down.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ups) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	if len(ups) != len(downs) {
+		t.Errorf("%d up migrations but %d down migrations", len(ups), len(downs))
+	}
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		if _, err := fs.Stat(migrations.FS, down); err != nil {
+			t.Errorf("%s has no matching %s", up, down)
+		}
+	}
+
+	latest, err := LatestMigrationVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(latest) != len(ups) {
+		t.Errorf("LatestMigrationVersion() = %d, expected %d with versions numbered from 1", latest, len(ups))
+	}
+}
+
+// TestMigrations_RoundTrip reverts every migration and applies them again.
+// It drops all tables, so it only runs against a database set aside for it.
+func TestMigrations_RoundTrip(t *testing.T) {
+	dbURL := os.Getenv("TEST_MIGRATIONS_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_MIGRATIONS_DATABASE_URL is not set")
+	}
+
+	latest, err := LatestMigrationVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(dbURL); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := MigrateDown(dbURL, int(latest)); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if _, _, ok, err := MigrationVersion(dbURL); err != nil || ok {
+		t.Fatalf("after down: ok = %t, err = %v; expected an empty schema", ok, err)
+	}
+	if err := Migrate(dbURL); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+
+	version, dirty, _, err := MigrationVersion(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != latest || dirty {
+		t.Errorf("schema at %d (dirty: %t), expected %d", version, dirty, latest)
+	}
+}
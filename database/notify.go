@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NotifyPublisher publishes events with pg_notify on a channel. Inside the
+// relay's unit of work the notification is sent when the transaction that
+// marks the event published commits, and not at all if it rolls back.
+type NotifyPublisher struct {
+	db      *pgxpool.Pool
+	channel string
+}
+
+func NewNotifyPublisher(db *pgxpool.Pool, channel string) *NotifyPublisher {
+	return &NotifyPublisher{db: db, channel: channel}
+}
+
+func (p *NotifyPublisher) Publish(ctx context.Context, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, p.db).Exec(ctx, "SELECT pg_notify($1, $2)", p.channel, string(payload))
+	return translateError(err)
+}
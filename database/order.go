@@ -5,10 +5,14 @@ import (
 	"errors"
 
 	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var _ repository.OrderRepository = (*OrderRepo)(nil)
+
 type OrderRepo struct {
 	db *pgxpool.Pool
 }
@@ -20,7 +24,7 @@ func NewOrderRepo(db *pgxpool.Pool) *OrderRepo {
 func (r *OrderRepo) CheckOrderExists(ctx context.Context, orderNumber string) (string, bool, error) {
 	var userID string
 
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		"SELECT user_id FROM orders WHERE number = $1",
 		orderNumber,
 	).Scan(&userID)
@@ -29,40 +33,174 @@ func (r *OrderRepo) CheckOrderExists(ctx context.Context, orderNumber string) (s
 		if errors.Is(err, pgx.ErrNoRows) {
 			return "", false, nil
 		}
-		return "", false, err
+		return "", false, translateError(err)
 	}
 
 	return userID, true, nil
 }
 
 func (r *OrderRepo) CreateOrder(ctx context.Context, order models.Order) error {
-	_, err := r.db.Exec(ctx,
+	_, err := conn(ctx, r.db).Exec(ctx,
 		"INSERT INTO orders (user_id, number) VALUES ($1, $2)",
 		order.UserID,
 		order.Number,
 	)
-	return err
+	return translateError(err)
 }
 
 func (r *OrderRepo) GetOrdersByUser(ctx context.Context, userID string) ([]models.Order, error) {
-	rows, err := r.db.Query(ctx,
-		`SELECT number, status, accrual, uploaded_at 
-         FROM orders 
-         WHERE user_id=$1 
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT number, status, accrual, bonus, uploaded_at
+         FROM orders
+         WHERE user_id=$1
          ORDER BY uploaded_at DESC`, userID)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	defer rows.Close()
 
 	var orders []models.Order
 	for rows.Next() {
 		var o models.Order
-		if err := rows.Scan(&o.Number, &o.Status, &o.Accrual, &o.UploadedAt); err != nil {
-			return nil, err
+		if err := rows.Scan(&o.Number, &o.Status, &o.Accrual, &o.Bonus, &o.UploadedAt); err != nil {
+			return nil, translateError(err)
 		}
 		orders = append(orders, o)
 	}
 
 	return orders, nil
 }
+
+func (r *OrderRepo) GetOrder(ctx context.Context, orderNumber string) (*models.Order, error) {
+	var o models.Order
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT id, number, user_id, status, accrual, bonus, uploaded_at
+         FROM orders
+         WHERE number = $1`,
+		orderNumber,
+	).Scan(&o.ID, &o.Number, &o.UserID, &o.Status, &o.Accrual, &o.Bonus, &o.UploadedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrOrderNotFound
+		}
+		return nil, translateError(err)
+	}
+	return &o, nil
+}
+
+func (r *OrderRepo) GetPendingOrders(ctx context.Context, after string, limit int) ([]models.Order, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, number, user_id, status, accrual, bonus, uploaded_at
+         FROM orders
+         WHERE status IN ('NEW', 'PROCESSING') AND number > $1
+         ORDER BY number
+         LIMIT $2`, after, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var o models.Order
+		if err := rows.Scan(&o.ID, &o.Number, &o.UserID, &o.Status, &o.Accrual, &o.Bonus, &o.UploadedAt); err != nil {
+			return nil, translateError(err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, translateError(rows.Err())
+}
+
+// UpdateOrderAccrual stores the accrual system's verdict for an order and, in
+// the same transaction, credits the member with the difference between the
+// new and the previously credited accrual plus bonus. Only PROCESSED orders
+// count as credited, so moving an order out of PROCESSED takes its points back.
+func (r *OrderRepo) UpdateOrderAccrual(ctx context.Context, orderNumber, status string, accrual, bonus float64) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, oldStatus string
+	var oldAccrual, oldBonus float64
+	err = tx.QueryRow(ctx,
+		`SELECT user_id, status, accrual, bonus FROM orders WHERE number = $1 FOR UPDATE`,
+		orderNumber,
+	).Scan(&userID, &oldStatus, &oldAccrual, &oldBonus)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return service.ErrOrderNotFound
+		}
+		return translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE orders SET status = $1, accrual = $2, bonus = $3 WHERE number = $4`,
+		status, accrual, bonus, orderNumber,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	if oldStatus != models.OrderStatusProcessed && status == models.OrderStatusProcessed {
+		err = appendEvent(ctx, tx, models.EventOrderProcessed, userID, models.OrderProcessedData{
+			Order: orderNumber, Accrual: accrual, Bonus: bonus,
+		})
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	delta := creditedAccrual(status, accrual+bonus) - creditedAccrual(oldStatus, oldAccrual+oldBonus)
+	if delta != 0 {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
+             VALUES ($1, $2, 0)
+             ON CONFLICT (user_id) DO UPDATE
+             SET current_balance = user_points.current_balance + EXCLUDED.current_balance,
+                 updated_at = NOW()`,
+			userID, delta,
+		)
+		if err != nil {
+			return translateError(err)
+		}
+
+		err = appendEvent(ctx, tx, models.EventPointsCredited, userID, models.PointsCreditedData{
+			Amount: delta, Source: models.CreditSourceOrder, Reference: orderNumber,
+		})
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	return translateError(tx.Commit(ctx))
+}
+
+func creditedAccrual(status string, accrual float64) float64 {
+	if status != models.OrderStatusProcessed {
+		return 0
+	}
+	return accrual
+}
+
+// CountOrdersByStatus reports how many orders are in each status. Statuses
+// without orders are left out.
+func (r *OrderRepo) CountOrdersByStatus(ctx context.Context) (map[string]int64, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, "SELECT status, count(*) FROM orders GROUP BY status")
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, translateError(err)
+		}
+		counts[status] = n
+	}
+	return counts, translateError(rows.Err())
+}
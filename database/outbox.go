@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.OutboxRepository = (*OutboxRepo)(nil)
+
+// outboxRelayLock is the advisory lock key a relay holds while it claims
+// events, so replicas do not claim the same events.
+const outboxRelayLock int64 = 0x6f7574626f78 // "outbox"
+
+// appendEvent records an event in the caller's transaction, so it is
+// published if and only if the change it describes commits.
+//
+// seq is taken when the row is inserted, not when the transaction commits, and
+// the relay cannot hold a member's events back behind one it cannot see yet.
+// appendEvent therefore locks the member's user_points row first: a
+// transaction with an earlier event for the member has committed by the time
+// the lock is granted, so per member, events commit in seq order. Every
+// change to a balance takes that lock anyway, so no new lock order is
+// introduced.
+func appendEvent(ctx context.Context, tx pgx.Tx, eventType, userID string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := lockMember(ctx, tx, userID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO outbox_events (event_id, type, user_id, data) VALUES ($1, $2, $3, $4)`,
+		uuid.New(), eventType, userID, payload,
+	)
+	return err
+}
+
+// lockMember locks the member's user_points row until tx ends, creating the
+// row when the member has none yet.
+func lockMember(ctx context.Context, tx pgx.Tx, userID string) error {
+	var one int
+	err := tx.QueryRow(ctx, "SELECT 1 FROM user_points WHERE user_id = $1 FOR UPDATE", userID).Scan(&one)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES ($1, 0, 0)
+         ON CONFLICT (user_id) DO NOTHING`, userID)
+	if err != nil {
+		return err
+	}
+	return tx.QueryRow(ctx, "SELECT 1 FROM user_points WHERE user_id = $1 FOR UPDATE", userID).Scan(&one)
+}
+
+type OutboxRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepo(db *pgxpool.Pool) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// ClaimEvents leases the events it returns by moving next_attempt_at to
+// leaseUntil. A leased event also holds back the member's later events, the
+// same way one waiting for a retry does.
+func (r *OutboxRepo) ClaimEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.Event, bool, error) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	if !ok {
+		return nil, false, errors.New("outbox events must be claimed in a unit of work")
+	}
+
+	var locked bool
+	if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", outboxRelayLock).Scan(&locked); err != nil {
+		return nil, false, translateError(err)
+	}
+	if !locked {
+		return nil, false, nil
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT e.seq, e.event_id, e.type, e.user_id, e.data, e.occurred_at, e.attempts
+         FROM outbox_events e
+         WHERE e.published_at IS NULL
+           AND e.next_attempt_at <= $1
+           AND NOT EXISTS (
+               SELECT 1 FROM outbox_events p
+               WHERE p.user_id = e.user_id AND p.published_at IS NULL
+                 AND p.seq < e.seq AND p.next_attempt_at > $1)
+         ORDER BY e.seq
+         LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, false, translateError(err)
+	}
+	defer rows.Close()
+
+	events := make([]models.Event, 0)
+	seqs := make([]int64, 0)
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.Seq, &e.ID, &e.Type, &e.UserID, &e.Data, &e.OccurredAt, &e.Attempts); err != nil {
+			return nil, false, translateError(err)
+		}
+		events = append(events, e)
+		seqs = append(seqs, e.Seq)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, translateError(err)
+	}
+	rows.Close()
+
+	_, err = tx.Exec(ctx, `UPDATE outbox_events SET next_attempt_at = $1 WHERE seq = ANY($2)`, leaseUntil, seqs)
+	if err != nil {
+		return nil, false, translateError(err)
+	}
+	return events, true, nil
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, seq int64, at time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox_events SET published_at = $1, attempts = attempts + 1, last_error = '' WHERE seq = $2`,
+		at, seq,
+	)
+	return translateError(err)
+}
+
+func (r *OutboxRepo) MarkFailed(ctx context.Context, seq int64, reason string, retryAt time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE seq = $3`,
+		reason, retryAt, seq,
+	)
+	return translateError(err)
+}
+
+func (r *OutboxRepo) ReleaseEvents(ctx context.Context, seqs []int64, at time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox_events SET next_attempt_at = $1 WHERE seq = ANY($2) AND published_at IS NULL`,
+		at, seqs,
+	)
+	return translateError(err)
+}
+
+func (r *OutboxRepo) PurgePublished(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM outbox_events WHERE published_at < $1`, cutoff)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return tag.RowsAffected(), nil
+}
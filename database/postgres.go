@@ -2,14 +2,142 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
 
+	"github.com/Guldana11/gophermart/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func InitDB(dbURL string) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(context.Background(), dbURL)
+// PoolConfig tunes the connection pool. Zero values keep the defaults of
+// pgxpool, or whatever the connection string sets.
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration
+
+	// StatementTimeout is set as statement_timeout on every connection, so no
+	// repository query can run longer. Migrations use their own connection
+	// and are not limited.
+	StatementTimeout time.Duration
+
+	// StartupTimeout bounds how long Connect keeps retrying a database that is
+	// not up yet.
+	StartupTimeout time.Duration
+}
+
+// PoolConfigFromEnv reads DB_MAX_CONNS, DB_MIN_CONNS, DB_MAX_CONN_LIFETIME,
+// DB_MAX_CONN_IDLE_TIME, DB_HEALTH_CHECK_PERIOD, DB_CONNECT_TIMEOUT,
+// DB_STATEMENT_TIMEOUT and DB_STARTUP_TIMEOUT. Statements time out after 5s
+// and start-up gives up after 30s unless configured otherwise.
+func PoolConfigFromEnv(getenv func(string) string) (PoolConfig, error) {
+	cfg := PoolConfig{
+		ConnectTimeout:   5 * time.Second,
+		StatementTimeout: 5 * time.Second,
+		StartupTimeout:   30 * time.Second,
+	}
+
+	for name, dst := range map[string]*int32{
+		"DB_MAX_CONNS": &cfg.MaxConns,
+		"DB_MIN_CONNS": &cfg.MinConns,
+	} {
+		if v := getenv(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = int32(n)
+		}
+	}
+
+	for name, dst := range map[string]*time.Duration{
+		"DB_MAX_CONN_LIFETIME":   &cfg.MaxConnLifetime,
+		"DB_MAX_CONN_IDLE_TIME":  &cfg.MaxConnIdleTime,
+		"DB_HEALTH_CHECK_PERIOD": &cfg.HealthCheckPeriod,
+		"DB_CONNECT_TIMEOUT":     &cfg.ConnectTimeout,
+		"DB_STATEMENT_TIMEOUT":   &cfg.StatementTimeout,
+		"DB_STARTUP_TIMEOUT":     &cfg.StartupTimeout,
+	} {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = d
+		}
+	}
+
+	if cfg.MaxConns > 0 && cfg.MinConns > cfg.MaxConns {
+		return cfg, fmt.Errorf("DB_MIN_CONNS (%d) exceeds DB_MAX_CONNS (%d)", cfg.MinConns, cfg.MaxConns)
+	}
+	return cfg, nil
+}
+
+func (cfg PoolConfig) apply(pc *pgxpool.Config) {
+	if cfg.MaxConns > 0 {
+		pc.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		pc.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		pc.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		pc.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		pc.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if cfg.ConnectTimeout > 0 {
+		pc.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	}
+	if cfg.StatementTimeout > 0 {
+		pc.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	pc.ConnConfig.Tracer = tracing.PgxTracer{}
+}
+
+// Connect opens the pool and waits until the database answers a ping,
+// retrying with exponential backoff for up to cfg.StartupTimeout. A service
+// that cannot reach its database fails here instead of on its first query.
+func Connect(ctx context.Context, dbURL string, cfg PoolConfig, logger *slog.Logger) (*pgxpool.Pool, error) {
+	pc, err := pgxpool.ParseConfig(dbURL)
 	if err != nil {
 		return nil, err
 	}
-	return pool, nil
+	cfg.apply(pc)
+
+	pool, err := pgxpool.NewWithConfig(ctx, pc)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(cfg.StartupTimeout)
+	backoff := 250 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err = pool.Ping(ctx)
+		if err == nil {
+			return pool, nil
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			pool.Close()
+			return nil, fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+		logger.WarnContext(ctx, "database not reachable, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
 }
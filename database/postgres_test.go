@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestPoolConfigFromEnv(t *testing.T) {
+	env := func(vals map[string]string) func(string) string {
+		return func(k string) string { return vals[k] }
+	}
+
+	cfg, err := PoolConfigFromEnv(env(map[string]string{
+		"DB_MAX_CONNS":         "20",
+		"DB_MIN_CONNS":         "2",
+		"DB_MAX_CONN_LIFETIME": "30m",
+		"DB_STATEMENT_TIMEOUT": "1500ms",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pc, err := pgxpool.ParseConfig("postgres://localhost/loyalty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultIdle := pc.MaxConnIdleTime
+	cfg.apply(pc)
+
+	if pc.MaxConns != 20 || pc.MinConns != 2 || pc.MaxConnLifetime != 30*time.Minute {
+		t.Errorf("pool sizing not applied: max %d, min %d, lifetime %s", pc.MaxConns, pc.MinConns, pc.MaxConnLifetime)
+	}
+	if pc.MaxConnIdleTime != defaultIdle {
+		t.Errorf("unset DB_MAX_CONN_IDLE_TIME changed the default to %s", pc.MaxConnIdleTime)
+	}
+	if got := pc.ConnConfig.RuntimeParams["statement_timeout"]; got != "1500" {
+		t.Errorf("statement_timeout = %q, expected 1500", got)
+	}
+	if pc.ConnConfig.ConnectTimeout != 5*time.Second {
+		t.Errorf("ConnectTimeout = %s, expected the 5s default", pc.ConnConfig.ConnectTimeout)
+	}
+
+	for _, bad := range []map[string]string{
+		{"DB_MAX_CONNS": "many"},
+		{"DB_STATEMENT_TIMEOUT": "-1s"},
+		{"DB_MAX_CONNS": "2", "DB_MIN_CONNS": "5"},
+	} {
+		if _, err := PoolConfigFromEnv(env(bad)); err == nil {
+			t.Errorf("PoolConfigFromEnv(%v) accepted an invalid setting", bad)
+		}
+	}
+}
+
+func TestConnect_GivesUpOnUnreachableDatabase(t *testing.T) {
+	cfg := PoolConfig{ConnectTimeout: 100 * time.Millisecond, StartupTimeout: 600 * time.Millisecond}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	start := time.Now()
+	_, err := Connect(context.Background(), "postgres://postgres@127.0.0.1:1/none", cfg, logger)
+	if err == nil {
+		t.Fatal("Connect() succeeded against a closed port")
+	}
+	if !strings.Contains(err.Error(), "attempts") {
+		t.Errorf("Connect() error = %v, expected the number of attempts", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Connect() took %s, expected it to respect the start-up timeout", elapsed)
+	}
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.ReferralRepository = (*ReferralRepo)(nil)
+
+type ReferralRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewReferralRepo(db *pgxpool.Pool) *ReferralRepo {
+	return &ReferralRepo{db: db}
+}
+
+func (r *ReferralRepo) GetUserByReferralCode(ctx context.Context, code string) (*models.User, error) {
+	var u models.User
+	err := scanUser(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE referral_code = $1`, code), &u)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrUserNotFound
+		}
+		return nil, translateError(err)
+	}
+	return &u, nil
+}
+
+func (r *ReferralRepo) CreateReferral(ctx context.Context, referral models.Referral) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO referrals (referee_id, referrer_id, status)
+         VALUES ($1, $2, $3)`,
+		referral.RefereeID, referral.ReferrerID, referral.Status,
+	)
+	return translateError(err)
+}
+
+// RewardReferral locks the pending referral and then the referrer's rewarded
+// referrals, so two referees finishing their first orders at once cannot push
+// the referrer past the cap.
+func (r *ReferralRepo) RewardReferral(ctx context.Context, refereeID, orderNumber string, referrerReward, refereeReward float64, maxRewards int) (*models.Referral, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	ref := models.Referral{RefereeID: refereeID}
+	err = tx.QueryRow(ctx,
+		`SELECT referrer_id, status, created_at
+         FROM referrals
+         WHERE referee_id = $1 AND status = $2
+         FOR UPDATE`,
+		refereeID, models.ReferralStatusPending,
+	).Scan(&ref.ReferrerID, &ref.Status, &ref.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, translateError(err)
+	}
+
+	_, err = tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, ref.ReferrerID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	var rewarded int
+	err = tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM referrals
+         WHERE referrer_id = $1 AND status = $2 AND referrer_reward > 0`,
+		ref.ReferrerID, models.ReferralStatusRewarded,
+	).Scan(&rewarded)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	ref.RefereeReward = refereeReward
+	if rewarded < maxRewards {
+		ref.ReferrerReward = referrerReward
+	}
+
+	// Credit in ID order so concurrent transactions lock points rows in the
+	// same order.
+	credits := []struct {
+		userID string
+		amount float64
+	}{{ref.ReferrerID, ref.ReferrerReward}, {refereeID, ref.RefereeReward}}
+	if credits[1].userID < credits[0].userID {
+		credits[0], credits[1] = credits[1], credits[0]
+	}
+
+	for _, credit := range credits {
+		if credit.amount <= 0 {
+			continue
+		}
+		_, err = tx.Exec(ctx,
+			`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
+             VALUES ($1, $2, 0)
+             ON CONFLICT (user_id) DO UPDATE
+             SET current_balance = user_points.current_balance + EXCLUDED.current_balance,
+                 updated_at = NOW()`,
+			credit.userID, credit.amount,
+		)
+		if err != nil {
+			return nil, translateError(err)
+		}
+
+		err = appendEvent(ctx, tx, models.EventPointsCredited, credit.userID, models.PointsCreditedData{
+			Amount: credit.amount, Source: models.CreditSourceReferral, Reference: orderNumber,
+		})
+		if err != nil {
+			return nil, translateError(err)
+		}
+	}
+
+	ref.Status = models.ReferralStatusRewarded
+	ref.OrderNumber = orderNumber
+	err = tx.QueryRow(ctx,
+		`UPDATE referrals
+         SET status = $1, order_number = $2, referrer_reward = $3, referee_reward = $4, rewarded_at = NOW()
+         WHERE referee_id = $5
+         RETURNING rewarded_at`,
+		ref.Status, ref.OrderNumber, ref.ReferrerReward, ref.RefereeReward, refereeID,
+	).Scan(&ref.RewardedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, translateError(err)
+	}
+	return &ref, nil
+}
+
+func (r *ReferralRepo) ListReferrals(ctx context.Context, referrerID string) ([]models.Referral, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT rf.referee_id, u.login, rf.referrer_id, rf.status, COALESCE(rf.order_number, ''),
+                rf.referrer_reward, rf.referee_reward, rf.created_at, rf.rewarded_at
+         FROM referrals rf
+         JOIN users u ON u.id = rf.referee_id
+         WHERE rf.referrer_id = $1
+         ORDER BY rf.created_at DESC`,
+		referrerID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	referrals := make([]models.Referral, 0)
+	for rows.Next() {
+		var ref models.Referral
+		if err := rows.Scan(&ref.RefereeID, &ref.RefereeLogin, &ref.ReferrerID, &ref.Status, &ref.OrderNumber,
+			&ref.ReferrerReward, &ref.RefereeReward, &ref.CreatedAt, &ref.RewardedAt); err != nil {
+			return nil, translateError(err)
+		}
+		referrals = append(referrals, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return referrals, nil
+}
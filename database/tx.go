@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.TxManager = (*TxManager)(nil)
+
+type txKey struct{}
+
+// querier is what the repositories run statements on: the pool, or the
+// transaction of the unit of work the context belongs to.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func conn(ctx context.Context, db *pgxpool.Pool) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// begin starts a transaction for a repository method. Inside a unit of work
+// it opens a savepoint instead, so the method still rolls back on its own
+// errors without ending the surrounding transaction.
+func begin(ctx context.Context, db *pgxpool.Pool) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return db.BeginTx(ctx, pgx.TxOptions{})
+}
+
+// TxManager runs units of work in serializable Postgres transactions and
+// retries those that fail with a serialization failure or deadlock.
+type TxManager struct {
+	db          *pgxpool.Pool
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewTxManager(db *pgxpool.Pool) *TxManager {
+	return &TxManager{db: db, maxAttempts: 3, logger: slog.Default()}
+}
+
+func (m *TxManager) SetLogger(logger *slog.Logger) {
+	m.logger = logger
+}
+
+// WithinTx runs fn in one transaction; every repository call made with the
+// context passed to fn joins it. fn is run again from scratch when the
+// transaction has to be retried, so it must not have effects outside the
+// database, and it must not use the context from several goroutines at once.
+// Nested calls join the outer transaction.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil || !retryable(err) {
+			return err
+		}
+		if attempt >= m.maxAttempts {
+			m.logger.WarnContext(ctx, "transaction conflicts persisted", "attempts", attempt, "error", err)
+			return err
+		}
+		m.logger.DebugContext(ctx, "transaction retried", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 20 * time.Millisecond):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return translateError(tx.Commit(ctx))
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, service.ErrSerialization) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected)
+}
@@ -2,9 +2,7 @@ package database
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"log"
 	"time"
 
 	"github.com/Guldana11/gophermart/models"
@@ -16,11 +14,14 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-//var ErrInsufficientFunds = errors.New("insufficient funds")
-//var ErrInvalidOrder = errors.New("invalid order")
-
 var _ repository.UserRepository = (*UserRepo)(nil)
 
+const userColumns = `id, login, password_hash, COALESCE(loyalty_card, ''), COALESCE(referral_code, ''), role, blocked_at, created_at`
+
+func scanUser(row pgx.Row, u *models.User) error {
+	return row.Scan(&u.ID, &u.Login, &u.PasswordHash, &u.LoyaltyCard, &u.ReferralCode, &u.Role, &u.BlockedAt, &u.CreatedAt)
+}
+
 type UserRepo struct {
 	db *pgxpool.Pool
 }
@@ -29,92 +30,128 @@ func NewUserRepo(db *pgxpool.Pool) *UserRepo {
 	return &UserRepo{db: db}
 }
 
+// CreateUser inserts the user and their points row in one transaction. A
+// duplicate login is detected by the unique constraint on users.login, so two
+// concurrent sign-ups cannot both pass a check and race into the insert.
 func (r *UserRepo) CreateUser(ctx context.Context, login, password string) (*models.User, error) {
-	var exists bool
-	err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE login=$1)", login).Scan(&exists)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
-	if exists {
-		return nil, errors.New("login already exists")
+
+	card, err := service.NewLoyaltyCardNumber()
+	if err != nil {
+		return nil, err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	referralCode, err := service.NewReferralCode()
 	if err != nil {
 		return nil, err
 	}
 
-	id := uuid.New().String()
-	createdAt := time.Now()
-	_, err = r.db.Exec(ctx,
-		"INSERT INTO users (id, login, password_hash, created_at) VALUES ($1,$2,$3,$4)",
-		id, login, string(hash), createdAt,
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Login:        login,
+		PasswordHash: string(hash),
+		LoyaltyCard:  card,
+		ReferralCode: referralCode,
+		Role:         models.RoleUser,
+		CreatedAt:    time.Now(),
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO users (id, login, password_hash, loyalty_card, referral_code, created_at) VALUES ($1,$2,$3,$4,$5,$6)",
+		user.ID, user.Login, user.PasswordHash, user.LoyaltyCard, user.ReferralCode, user.CreatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
-	initialBalance := 729.98
-	_, err = r.db.Exec(ctx,
-		"INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES ($1, $2, $3)",
-		id, initialBalance, 0,
+	_, err = tx.Exec(ctx,
+		"INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES ($1, 0, 0)",
+		user.ID,
 	)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
-	return &models.User{
-		ID:           id,
-		Login:        login,
-		PasswordHash: string(hash),
-		CreatedAt:    createdAt,
-	}, nil
+	if err := tx.Commit(ctx); err != nil {
+		return nil, translateError(err)
+	}
+	return user, nil
 }
 
 func (r *UserRepo) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
 	var u models.User
-	err := r.db.QueryRow(ctx,
-		"SELECT id, login, password_hash, created_at FROM users WHERE login=$1", login).
-		Scan(&u.ID, &u.Login, &u.PasswordHash, &u.CreatedAt)
+	err := scanUser(conn(ctx, r.db).QueryRow(ctx,
+		"SELECT "+userColumns+" FROM users WHERE login=$1", login), &u)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrUserNotFound
+		}
+		return nil, translateError(err)
 	}
 	return &u, nil
 }
 
 func (r *UserRepo) GetUserPoints(ctx context.Context, userID string) (float64, float64, error) {
 	var current, withdrawn float64
-	err := r.db.QueryRow(ctx,
+	err := conn(ctx, r.db).QueryRow(ctx,
 		`SELECT current_balance, withdrawn_points 
          FROM user_points 
          WHERE user_id = $1`, userID).
 		Scan(&current, &withdrawn)
 
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			_, err := r.db.Exec(ctx,
-				`INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES ($1, 0, 0)`, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			_, err := conn(ctx, r.db).Exec(ctx,
+				`INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES ($1, 0, 0)
+                 ON CONFLICT (user_id) DO NOTHING`, userID)
 			if err != nil {
-				return 0, 0, err
+				return 0, 0, translateError(err)
 			}
 			return 0, 0, nil
 		}
-		return 0, 0, err
+		return 0, 0, translateError(err)
 	}
 	return current, withdrawn, nil
 }
 
+// lockOrderNumber holds a transaction-scoped lock on an order number, so that
+// a withdrawal and a hold for the same order cannot both pass the check that
+// the number is unused: each table's unique index only covers itself, and the
+// per-member row lock is taken after the check and does not cover two
+// members spending the same number.
+func lockOrderNumber(ctx context.Context, tx pgx.Tx, order string) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", order)
+	return translateError(err)
+}
+
 func (r *UserRepo) Withdraw(ctx context.Context, userID string, order string, sum float64) error {
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := begin(ctx, r.db)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	defer tx.Rollback(ctx)
 
+	if err := lockOrderNumber(ctx, tx, order); err != nil {
+		return err
+	}
+
 	var exists bool
-	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM withdrawals WHERE order_number=$1)`, order).Scan(&exists)
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM withdrawals WHERE order_number=$1)
+             OR EXISTS (SELECT 1 FROM point_holds WHERE order_number=$1)`,
+		order,
+	).Scan(&exists)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	if exists {
 		return service.ErrInvalidOrder
@@ -134,11 +171,11 @@ func (r *UserRepo) Withdraw(ctx context.Context, userID string, order string, su
 				`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
                  VALUES ($1, 0, 0)`, userID)
 			if err != nil {
-				return err
+				return translateError(err)
 			}
 			current = 0
 		} else {
-			return err
+			return translateError(err)
 		}
 	}
 
@@ -154,7 +191,7 @@ func (r *UserRepo) Withdraw(ctx context.Context, userID string, order string, su
 		sum, userID,
 	)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	if res.RowsAffected() == 0 {
 		return service.ErrInvalidOrder
@@ -166,14 +203,19 @@ func (r *UserRepo) Withdraw(ctx context.Context, userID string, order string, su
 		userID, order, sum,
 	)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
-	return tx.Commit(ctx)
+	err = appendEvent(ctx, tx, models.EventPointsWithdrawn, userID, models.PointsWithdrawnData{Order: order, Sum: sum})
+	if err != nil {
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit(ctx))
 }
 
 func (r *UserRepo) GetUserWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error) {
-	rows, err := r.db.Query(ctx,
+	rows, err := conn(ctx, r.db).Query(ctx,
 		`SELECT order_number, sum, processed_at
 		 FROM public.withdrawals
 		 WHERE user_id = $1
@@ -181,10 +223,9 @@ func (r *UserRepo) GetUserWithdrawals(ctx context.Context, userID string) ([]mod
 		userID,
 	)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	defer rows.Close()
-	log.Println("GetUserWithdrawals: querying withdrawals table")
 
 	withdrawals := make([]models.Withdrawal, 0)
 	for rows.Next() {
@@ -194,12 +235,12 @@ func (r *UserRepo) GetUserWithdrawals(ctx context.Context, userID string) ([]mod
 			&w.Sum,
 			&w.ProcessedAt,
 		); err != nil {
-			return nil, err
+			return nil, translateError(err)
 		}
 		withdrawals = append(withdrawals, w)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return withdrawals, nil
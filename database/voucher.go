@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.VoucherRepository = (*VoucherRepo)(nil)
+
+type VoucherRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewVoucherRepo(db *pgxpool.Pool) *VoucherRepo {
+	return &VoucherRepo{db: db}
+}
+
+func (r *VoucherRepo) CreateBatch(ctx context.Context, batch models.VoucherBatch, codeHashes []string) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO voucher_batches (id, name, value, count, expires_at, created_by, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		batch.ID, batch.Name, batch.Value, batch.Count, batch.ExpiresAt, batch.CreatedBy, batch.CreatedAt,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO vouchers (code_hash, batch_id, value, expires_at)
+         SELECT h, $2, $3, $4 FROM unnest($1::TEXT[]) AS h`,
+		codeHashes, batch.ID, batch.Value, batch.ExpiresAt,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit(ctx))
+}
+
+// RedeemVoucher locks the voucher row, so of two concurrent redemptions the
+// second one waits and then sees the voucher as already redeemed.
+func (r *VoucherRepo) RedeemVoucher(ctx context.Context, codeHash, userID string, now time.Time) (*models.VoucherRedemption, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var value float64
+	var expiresAt, redeemedAt *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT value, expires_at, redeemed_at FROM vouchers WHERE code_hash = $1 FOR UPDATE`,
+		codeHash,
+	).Scan(&value, &expiresAt, &redeemedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, service.ErrVoucherNotFound
+		}
+		return nil, translateError(err)
+	}
+	if redeemedAt != nil {
+		return nil, service.ErrVoucherRedeemed
+	}
+	if expiresAt != nil && !now.Before(*expiresAt) {
+		return nil, service.ErrVoucherExpired
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE vouchers SET redeemed_by = $1, redeemed_at = $2 WHERE code_hash = $3`,
+		userID, now, codeHash,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
+         VALUES ($1, $2, 0)
+         ON CONFLICT (user_id) DO UPDATE
+         SET current_balance = user_points.current_balance + EXCLUDED.current_balance,
+             updated_at = NOW()`,
+		userID, value,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	err = appendEvent(ctx, tx, models.EventPointsCredited, userID, models.PointsCreditedData{
+		Amount: value, Source: models.CreditSourceVoucher,
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, translateError(err)
+	}
+	return &models.VoucherRedemption{Value: value, RedeemedAt: now}, nil
+}
+
+func (r *VoucherRepo) BatchReports(ctx context.Context, now time.Time) ([]models.VoucherBatchReport, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT b.id, b.name, b.value, b.count, b.expires_at, b.created_by, b.created_at,
+                COALESCE(SUM(v.value), 0),
+                COUNT(v.redeemed_at),
+                COALESCE(SUM(v.value) FILTER (WHERE v.redeemed_at IS NOT NULL), 0),
+                COUNT(*) FILTER (WHERE v.redeemed_at IS NULL AND v.expires_at <= $1),
+                COALESCE(SUM(v.value) FILTER (WHERE v.redeemed_at IS NULL AND v.expires_at <= $1), 0)
+         FROM voucher_batches b
+         LEFT JOIN vouchers v ON v.batch_id = b.id
+         GROUP BY b.id
+         ORDER BY b.created_at DESC`,
+		now,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	reports := make([]models.VoucherBatchReport, 0)
+	for rows.Next() {
+		var rep models.VoucherBatchReport
+		if err := rows.Scan(&rep.ID, &rep.Name, &rep.Value, &rep.Count, &rep.ExpiresAt, &rep.CreatedBy, &rep.CreatedAt,
+			&rep.IssuedValue, &rep.Redeemed, &rep.RedeemedValue, &rep.Expired, &rep.ExpiredValue); err != nil {
+			return nil, translateError(err)
+		}
+		reports = append(reports, rep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return reports, nil
+}
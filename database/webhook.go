@@ -0,0 +1,334 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.WebhookRepository = (*WebhookRepo)(nil)
+
+// webhookDeliveryLock is the advisory lock key a worker holds while it
+// claims deliveries, so replicas do not claim the same ones.
+const webhookDeliveryLock int64 = 0x776562686f6f6b // "webhook"
+
+const webhookEndpointColumns = `id, url, events, COALESCE(user_id::TEXT, ''), COALESCE(merchant_id::TEXT, ''),
+                created_at, consecutive_failures, disabled_at, disabled_reason`
+
+// webhookOwned matches the endpoints of the owner passed as $1 (member) and
+// $2 (merchant); the unset one is NULL and matches nothing.
+const webhookOwned = `(user_id = NULLIF($1, '')::UUID OR merchant_id = NULLIF($2, '')::UUID)`
+
+type WebhookRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepo(db *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+func (r *WebhookRepo) CreateEndpoint(ctx context.Context, e models.WebhookEndpoint, secret string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO webhook_endpoints (id, user_id, merchant_id, url, secret, events, created_at)
+         VALUES ($1, NULLIF($2, '')::UUID, NULLIF($3, '')::UUID, $4, $5, $6, $7)`,
+		e.ID, e.UserID, e.MerchantID, e.URL, secret, e.Events, e.CreatedAt,
+	)
+	return translateError(err)
+}
+
+func (r *WebhookRepo) CountEndpoints(ctx context.Context, owner models.WebhookOwner) (int, error) {
+	var n int
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT COUNT(*) FROM webhook_endpoints WHERE `+webhookOwned,
+		owner.UserID, owner.MerchantID,
+	).Scan(&n)
+	return n, translateError(err)
+}
+
+func (r *WebhookRepo) ListEndpoints(ctx context.Context, owner models.WebhookOwner) ([]models.WebhookEndpoint, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+webhookEndpointColumns+`
+         FROM webhook_endpoints
+         WHERE `+webhookOwned+`
+         ORDER BY created_at DESC`,
+		owner.UserID, owner.MerchantID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	endpoints := make([]models.WebhookEndpoint, 0)
+	for rows.Next() {
+		var e models.WebhookEndpoint
+		if err := rows.Scan(&e.ID, &e.URL, &e.Events, &e.UserID, &e.MerchantID,
+			&e.CreatedAt, &e.ConsecutiveFailures, &e.DisabledAt, &e.DisabledReason); err != nil {
+			return nil, translateError(err)
+		}
+		endpoints = append(endpoints, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return endpoints, nil
+}
+
+func (r *WebhookRepo) DeleteEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM webhook_endpoints WHERE id = $3 AND `+webhookOwned,
+		owner.UserID, owner.MerchantID, id,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepo) EnableEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_endpoints
+         SET disabled_at = NULL, disabled_reason = '', consecutive_failures = 0
+         WHERE id = $3 AND `+webhookOwned,
+		owner.UserID, owner.MerchantID, id,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepo) DisableEndpoint(ctx context.Context, id, reason string, at time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_endpoints
+         SET disabled_at = COALESCE(disabled_at, $2), disabled_reason = $3
+         WHERE id = $1`,
+		id, at, reason,
+	)
+	return translateError(err)
+}
+
+// EnqueueDeliveries matches merchants through merchant_purchases, by the order
+// number in the event data.
+func (r *WebhookRepo) EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte, at time.Time) (int, error) {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, next_attempt_at, created_at)
+         SELECT w.id, $1, $2, $3, $4, $4
+         FROM webhook_endpoints w
+         WHERE w.disabled_at IS NULL
+           AND (cardinality(w.events) = 0 OR $2 = ANY(w.events))
+           AND (w.user_id = $5
+                OR ($2 = $6 AND w.merchant_id = (
+                    SELECT merchant_id FROM merchant_purchases WHERE order_number = $7::JSONB->>'order')))
+         ON CONFLICT (endpoint_id, event_id) DO NOTHING`,
+		event.ID, event.Type, payload, at, event.UserID, models.EventOrderProcessed, string(event.Data),
+	)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return int(res.RowsAffected()), nil
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, owner models.WebhookOwner, endpointID string, beforeID int64, limit int) ([]models.WebhookDelivery, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM webhook_endpoints WHERE id = $3 AND `+webhookOwned+`)`,
+		owner.UserID, owner.MerchantID, endpointID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if !exists {
+		return nil, service.ErrWebhookNotFound
+	}
+
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id, endpoint_id, event_id, event_type, status, attempts, last_status_code, last_error,
+                created_at, CASE WHEN status = $4 THEN next_attempt_at END, delivered_at
+         FROM webhook_deliveries
+         WHERE endpoint_id = $1 AND ($2 = 0 OR id < $2)
+         ORDER BY id DESC
+         LIMIT $3`,
+		endpointID, beforeID, limit, models.DeliveryStatusPending,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.NextAttemptAt, &d.DeliveredAt); err != nil {
+			return nil, translateError(err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) ReplayDelivery(ctx context.Context, owner models.WebhookOwner, endpointID string, id int64, at time.Time) error {
+	res, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_deliveries d
+         SET status = $5, attempts = 0, next_attempt_at = $6, delivered_at = NULL
+         FROM webhook_endpoints w
+         WHERE d.id = $3 AND d.endpoint_id = $4 AND w.id = d.endpoint_id
+           AND (w.user_id = NULLIF($1, '')::UUID OR w.merchant_id = NULLIF($2, '')::UUID)`,
+		owner.UserID, owner.MerchantID, id, endpointID, models.DeliveryStatusPending, at,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return service.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// ClaimDeliveries leases the deliveries it returns by moving next_attempt_at
+// to leaseUntil. A leased delivery also holds back the endpoint's later ones,
+// the same way one waiting for a retry does.
+func (r *WebhookRepo) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, bool, error) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	if !ok {
+		return nil, false, errors.New("webhook deliveries must be claimed in a unit of work")
+	}
+
+	var locked bool
+	if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", webhookDeliveryLock).Scan(&locked); err != nil {
+		return nil, false, translateError(err)
+	}
+	if !locked {
+		return nil, false, nil
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.attempts, w.url, w.secret
+         FROM webhook_deliveries d
+         JOIN webhook_endpoints w ON w.id = d.endpoint_id
+         WHERE d.status = $1
+           AND d.next_attempt_at <= $2
+           AND w.disabled_at IS NULL
+           AND NOT EXISTS (
+               SELECT 1 FROM webhook_deliveries p
+               WHERE p.endpoint_id = d.endpoint_id AND p.status = $1
+                 AND p.id < d.id AND p.next_attempt_at > $2)
+         ORDER BY d.id
+         LIMIT $3`,
+		models.DeliveryStatusPending, now, limit,
+	)
+	if err != nil {
+		return nil, false, translateError(err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	ids := make([]int64, 0)
+	for rows.Next() {
+		d := models.WebhookDelivery{Status: models.DeliveryStatusPending}
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Attempts, &d.URL, &d.Secret); err != nil {
+			return nil, false, translateError(err)
+		}
+		deliveries = append(deliveries, d)
+		ids = append(ids, d.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, translateError(err)
+	}
+	rows.Close()
+
+	_, err = tx.Exec(ctx, `UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id = ANY($2)`, leaseUntil, ids)
+	if err != nil {
+		return nil, false, translateError(err)
+	}
+	return deliveries, true, nil
+}
+
+func (r *WebhookRepo) ReleaseDeliveries(ctx context.Context, ids []int64, at time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id = ANY($2) AND status = $3`,
+		at, ids, models.DeliveryStatusPending,
+	)
+	return translateError(err)
+}
+
+func (r *WebhookRepo) MarkDelivered(ctx context.Context, d models.WebhookDelivery, statusCode int, at time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE webhook_deliveries
+         SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = '', delivered_at = $3
+         WHERE id = $4`,
+		models.DeliveryStatusDelivered, statusCode, at, d.ID,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE webhook_endpoints SET consecutive_failures = 0 WHERE id = $1`, d.EndpointID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit(ctx))
+}
+
+func (r *WebhookRepo) MarkDeliveryFailed(ctx context.Context, d models.WebhookDelivery, statusCode int, reason string, retryAt *time.Time) (int, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	status := models.DeliveryStatusPending
+	if retryAt == nil {
+		status = models.DeliveryStatusFailed
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE webhook_deliveries
+         SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = $3,
+             next_attempt_at = COALESCE($4, next_attempt_at)
+         WHERE id = $5`,
+		status, statusCode, reason, retryAt, d.ID,
+	)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	var failures int
+	err = tx.QueryRow(ctx,
+		`UPDATE webhook_endpoints SET consecutive_failures = consecutive_failures + 1
+         WHERE id = $1
+         RETURNING consecutive_failures`,
+		d.EndpointID,
+	).Scan(&failures)
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, translateError(err)
+	}
+	return failures, nil
+}
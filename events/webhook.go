@@ -0,0 +1,129 @@
+// Package events delivers outbox events to systems outside the service.
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Headers sent with every webhook request.
+const (
+	HeaderEventID   = "Gophermart-Event-Id"
+	HeaderEventType = "Gophermart-Event-Type"
+	HeaderSignature = "Gophermart-Signature"
+)
+
+// Sign returns the signature header value for body sent at ts:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers recompute
+// it with the shared secret and reject stale timestamps to stop replays.
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header produced by Sign for body, and that it
+// was made no more than tolerance before now.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			v1 = v
+		}
+	}
+	sec, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || v1 == "" {
+		return errors.New("malformed signature")
+	}
+
+	ts := time.Unix(sec, 0)
+	if now.Sub(ts) > tolerance || ts.Sub(now) > tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	_, expected, _ := strings.Cut(Sign(secret, ts, body), ",v1=")
+	if !hmac.Equal([]byte(expected), []byte(v1)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// WebhookPublisher POSTs each event as JSON to a fixed URL. Any response
+// other than 2xx counts as a failure, so the event is retried.
+type WebhookPublisher struct {
+	url    string
+	secret string
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookPublisher signs requests with secret unless it is empty.
+func NewWebhookPublisher(url, secret string) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		secret: secret,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		now: time.Now,
+	}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event models.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	status, err := Post(ctx, p.client, p.url, p.secret, p.now(), event, body)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("webhook responded %d", status)
+	}
+	return nil
+}
+
+// Post sends body as the JSON encoding of event and returns the response
+// status. The response body is drained so the connection can be reused.
+func Post(ctx context.Context, client *http.Client, url, secret string, at time.Time, event models.Event, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, event.Type)
+	if secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, at, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
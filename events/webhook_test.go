@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"1"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	assert.Equal(t, "t=1700000000,v1="+hex.EncodeToString(mac.Sum(nil)), Sign("secret", at, body))
+	assert.NotEqual(t, Sign("secret", at, body), Sign("other", at, body))
+}
+
+func TestWebhookPublisher_Publish(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	event := models.Event{
+		Seq: 7, ID: "e1", Type: models.EventPointsWithdrawn, UserID: "u1",
+		OccurredAt: at.UTC(), Data: json.RawMessage(`{"order":"123","sum":5}`),
+	}
+
+	var got *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := NewWebhookPublisher(srv.URL, "secret")
+	p.now = func() time.Time { return at }
+
+	require.NoError(t, p.Publish(context.Background(), event))
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "e1", got.Header.Get(HeaderEventID))
+	assert.Equal(t, models.EventPointsWithdrawn, got.Header.Get(HeaderEventType))
+	assert.Equal(t, Sign("secret", at, body), got.Header.Get(HeaderSignature))
+
+	var decoded models.Event
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, event.Seq, decoded.Seq)
+	assert.JSONEq(t, string(event.Data), string(decoded.Data))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, p.Publish(context.Background(), event))
+
+	unsigned := NewWebhookPublisher(srv.URL, "")
+	status = http.StatusOK
+	require.NoError(t, unsigned.Publish(context.Background(), event))
+	assert.Empty(t, got.Header.Get(HeaderSignature))
+}
+
+func TestVerify(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"1"}`)
+	header := Sign("secret", at, body)
+
+	assert.NoError(t, Verify("secret", header, body, time.Minute, at.Add(30*time.Second)))
+	assert.Error(t, Verify("other", header, body, time.Minute, at), "wrong secret")
+	assert.Error(t, Verify("secret", header, []byte(`{"id":"2"}`), time.Minute, at), "tampered body")
+	assert.Error(t, Verify("secret", header, body, time.Minute, at.Add(2*time.Minute)), "stale")
+	assert.Error(t, Verify("secret", "v1=abc", body, time.Minute, at), "malformed")
+}
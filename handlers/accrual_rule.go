@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AccrualRuleHandler struct {
+	ruleService service.AccrualRuleServiceType
+}
+
+func NewAccrualRuleHandler(ruleSvc service.AccrualRuleServiceType) *AccrualRuleHandler {
+	return &AccrualRuleHandler{ruleService: ruleSvc}
+}
+
+func (h *AccrualRuleHandler) CreateRule(c *gin.Context) {
+	var req models.CreateAccrualRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+
+	rule, err := h.ruleService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *AccrualRuleHandler) ListRules(c *gin.Context) {
+	rules, err := h.ruleService.ListRules(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *AccrualRuleHandler) DisableRule(c *gin.Context) {
+	if err := h.ruleService.DisableRule(c.Request.Context(), c.Param("id")); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
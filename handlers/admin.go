@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService service.AdminServiceType
+}
+
+func NewAdminHandler(adminSvc service.AdminServiceType) *AdminHandler {
+	return &AdminHandler{adminService: adminSvc}
+}
+
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	users, err := h.adminService.SearchUsers(c.Request.Context(), c.Query("login"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *AdminHandler) GetAccount(c *gin.Context) {
+	account, err := h.adminService.GetAccount(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *AdminHandler) GetOrders(c *gin.Context) {
+	orders, err := h.adminService.GetOrders(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if orders == nil {
+		orders = []models.Order{}
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *AdminHandler) GetWithdrawals(c *gin.Context) {
+	withdrawals, err := h.adminService.GetWithdrawals(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+func (h *AdminHandler) GetAdjustments(c *gin.Context) {
+	adjustments, err := h.adminService.GetAdjustments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustments)
+}
+
+func (h *AdminHandler) BlockUser(c *gin.Context) {
+	if err := h.adminService.SetBlocked(c.Request.Context(), c.Param("id"), true); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminHandler) UnblockUser(c *gin.Context) {
+	if err := h.adminService.SetBlocked(c.Request.Context(), c.Param("id"), false); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	var req models.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+
+	if err := h.adminService.SetRole(c.Request.Context(), c.Param("id"), req.Role); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminHandler) AdjustBalance(c *gin.Context) {
+	var req models.BalanceAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+
+	adj, err := h.adminService.AdjustBalance(c.Request.Context(), actorFromContext(c), c.Param("id"), req.Amount, req.Reason)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, adj)
+}
+
+// actorFromContext names the caller for records such as balance adjustments:
+// "user:<id>" for sessions and "apikey:<id>" for API keys.
+func actorFromContext(c *gin.Context) string {
+	if keyID := c.GetString("apiKeyID"); keyID != "" {
+		return "apikey:" + keyID
+	}
+	if userID := strings.TrimSpace(c.GetString("userID")); userID != "" {
+		return "user:" + userID
+	}
+	return "unknown"
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAdminService struct {
+	service.AdminServiceType
+	AdjustBalanceFunc func(ctx context.Context, actor, userID string, amount float64, reason string) (*models.BalanceAdjustment, error)
+	SetBlockedFunc    func(ctx context.Context, userID string, blocked bool) error
+}
+
+func (m *MockAdminService) AdjustBalance(ctx context.Context, actor, userID string, amount float64, reason string) (*models.BalanceAdjustment, error) {
+	return m.AdjustBalanceFunc(ctx, actor, userID, amount, reason)
+}
+
+func (m *MockAdminService) SetBlocked(ctx context.Context, userID string, blocked bool) error {
+	return m.SetBlockedFunc(ctx, userID, blocked)
+}
+
+func TestAdminHandler_AdjustBalance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		role           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"member forbidden", models.RoleUser, `{"amount":10,"reason":"x"}`, nil, http.StatusForbidden},
+		{"support forbidden", models.RoleSupport, `{"amount":10,"reason":"x"}`, nil, http.StatusForbidden},
+		{"invalid json", models.RoleAdmin, `{invalid}`, nil, http.StatusBadRequest},
+		{"missing reason", models.RoleAdmin, `{"amount":10}`, service.ErrInvalidAdjustment, http.StatusUnprocessableEntity},
+		{"unknown user", models.RoleAdmin, `{"amount":10,"reason":"x"}`, service.ErrUserNotFound, http.StatusNotFound},
+		{"would go negative", models.RoleAdmin, `{"amount":-1000,"reason":"x"}`, service.ErrInsufficientFunds, http.StatusPaymentRequired},
+		{"created", models.RoleAdmin, `{"amount":10,"reason":"goodwill"}`, nil, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockAdminService{
+				AdjustBalanceFunc: func(ctx context.Context, actor, userID string, amount float64, reason string) (*models.BalanceAdjustment, error) {
+					assert.Equal(t, "user:admin-1", actor)
+					assert.Equal(t, "target", userID)
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &models.BalanceAdjustment{UserID: userID, Amount: amount, Reason: reason, Actor: actor}, nil
+				},
+			}
+			h := NewAdminHandler(svc)
+
+			r := gin.New()
+			r.POST("/api/admin/users/:id/adjustments", func(c *gin.Context) {
+				c.Set("userID", "admin-1")
+				c.Set("role", tt.role)
+			}, middleware.RequireRole(models.RoleAdmin), h.AdjustBalance)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/target/adjustments", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+type memberStatus struct {
+	role    string
+	blocked bool
+}
+
+type statusChecker map[string]memberStatus
+
+func (s statusChecker) UserStatus(ctx context.Context, userID string) (string, bool, error) {
+	status, ok := s[userID]
+	if !ok {
+		return "", false, service.ErrUserNotFound
+	}
+	return status.role, status.blocked, nil
+}
+
+// A token keeps the role it was issued with; the role checked is the
+// member's current one.
+func TestRequireRole_UsesCurrentRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checker := statusChecker{
+		"demoted":  {role: models.RoleUser},
+		"promoted": {role: models.RoleAdmin},
+	}
+
+	tests := []struct {
+		name           string
+		userID         string
+		tokenRole      string
+		expectedStatus int
+	}{
+		{"demoted admin", "demoted", models.RoleAdmin, http.StatusForbidden},
+		{"promoted member", "promoted", models.RoleUser, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Set("role", tt.tokenRole)
+			}, middleware.RejectBlocked(checker), middleware.RequireRole(models.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRejectBlocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	checker := statusChecker{
+		"active":  {role: models.RoleUser},
+		"blocked": {role: models.RoleUser, blocked: true},
+	}
+
+	tests := []struct {
+		name           string
+		userID         string
+		expectedStatus int
+	}{
+		{"active member", "active", http.StatusOK},
+		{"blocked member", "blocked", http.StatusForbidden},
+		{"deleted member", "deleted", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+			}, middleware.RejectBlocked(checker), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyServiceType
+}
+
+func NewAPIKeyHandler(apiKeySvc service.APIKeyServiceType) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeySvc}
+}
+
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, requiredField("name")))
+		return
+	}
+
+	key, err := h.apiKeyService.CreateUserKey(c.Request.Context(), userID, req.Name, req.Scopes)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), userID)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if len(keys) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	err := h.apiKeyService.RevokeKey(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// errKeyFromKey refuses requests that try to issue a key with an API key:
+// only interactive sessions and store tokens may.
+var errKeyFromKey = middleware.NewAPIError(http.StatusForbidden, middleware.CodeForbidden, "api keys cannot issue api keys")
+
+// CreateMerchantKey lets a store issue keys for its own integrations.
+func (h *APIKeyHandler) CreateMerchantKey(c *gin.Context) {
+	merchantID := c.GetString("merchantID")
+	if strings.TrimSpace(merchantID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+	if _, ok := c.Get("apiKeyID"); ok {
+		middleware.AbortWithError(c, errKeyFromKey)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, requiredField("name")))
+		return
+	}
+
+	key, err := h.apiKeyService.CreateMerchantKey(c.Request.Context(), merchantID, req.Name, req.Scopes)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// CreateServiceKey lets admins issue a key for a store, or a service key,
+// which is the only kind that may hold the admin scope.
+func (h *APIKeyHandler) CreateServiceKey(c *gin.Context) {
+	if _, ok := c.Get("apiKeyID"); ok {
+		middleware.AbortWithError(c, errKeyFromKey)
+		return
+	}
+
+	var req models.CreateServiceKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, requiredField("name")))
+		return
+	}
+
+	var key *models.CreatedAPIKey
+	var err error
+	if req.MerchantID != "" {
+		key, err = h.apiKeyService.CreateMerchantKey(c.Request.Context(), req.MerchantID, req.Name, req.Scopes)
+	} else {
+		key, err = h.apiKeyService.CreateServiceKey(c.Request.Context(), req.Name, req.Scopes)
+	}
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
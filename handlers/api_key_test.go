@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPIKeyAuthenticator map[string]models.APIKey
+
+func (m mockAPIKeyAuthenticator) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	k, ok := m[key]
+	if !ok {
+		return nil, service.ErrInvalidAPIKey
+	}
+	return &k, nil
+}
+
+func TestAPIKeyAuth_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := mockAPIKeyAuthenticator{
+		"gm_reader": {ID: "k1", UserID: "user-1", Scopes: []string{models.ScopeBalanceRead}},
+		"gm_admin":  {ID: "k2", UserID: "user-1", Scopes: []string{models.ScopeAdmin}},
+		"gm_store":  {ID: "k3", MerchantID: "merchant-1", Scopes: []string{models.ScopeOrdersWrite}},
+	}
+
+	handler := NewUserHandler(&MockBalanceService{
+		GetUserBalanceFunc: func(ctx context.Context, userID string) (float64, float64, error) {
+			return 10, 0, nil
+		},
+	})
+
+	r := gin.New()
+	auth := r.Group("/api", middleware.APIKeyAuth(keys), middleware.AuthMiddlewareJWT())
+	auth.GET("/user/balance", middleware.RequireScope(models.ScopeBalanceRead), handler.GetBalance)
+	auth.POST("/user/orders", middleware.RequireScope(models.ScopeOrdersWrite), func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		key            string
+		expectedStatus int
+	}{
+		{"no credentials", http.MethodGet, "/api/user/balance", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/api/user/balance", "gm_unknown", http.StatusUnauthorized},
+		{"scope granted", http.MethodGet, "/api/user/balance", "gm_reader", http.StatusOK},
+		{"scope missing", http.MethodPost, "/api/user/orders", "gm_reader", http.StatusForbidden},
+		{"admin covers all scopes", http.MethodPost, "/api/user/orders", "gm_admin", http.StatusAccepted},
+		{"merchant key has no member", http.MethodGet, "/api/user/balance", "gm_store", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(middleware.APIKeyHeader, tt.key)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+type MockAPIKeyService struct {
+	service.APIKeyServiceType
+	issued []models.APIKey
+}
+
+func (m *MockAPIKeyService) CreateMerchantKey(ctx context.Context, merchantID, name string, scopes []string) (*models.CreatedAPIKey, error) {
+	if merchantID == "missing" {
+		return nil, service.ErrMerchantNotFound
+	}
+	key := models.APIKey{ID: "k1", Name: name, MerchantID: merchantID, Scopes: scopes}
+	m.issued = append(m.issued, key)
+	return &models.CreatedAPIKey{APIKey: key, Key: "gm_x"}, nil
+}
+
+func (m *MockAPIKeyService) CreateServiceKey(ctx context.Context, name string, scopes []string) (*models.CreatedAPIKey, error) {
+	key := models.APIKey{ID: "k2", Name: name, Scopes: scopes}
+	m.issued = append(m.issued, key)
+	return &models.CreatedAPIKey{APIKey: key, Key: "gm_y"}, nil
+}
+
+func TestAPIKeyHandler_IssueKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	withCaller := func(merchantID, apiKeyID string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if merchantID != "" {
+				c.Set("merchantID", merchantID)
+			}
+			if apiKeyID != "" {
+				c.Set("apiKeyID", apiKeyID)
+			}
+		}
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		merchantID     string
+		apiKeyID       string
+		body           string
+		expectedStatus int
+		wantMerchant   string
+	}{
+		{"store issues its own key", "/merchant", "m1", "", `{"name":"pos","scopes":["orders:write"]}`, http.StatusCreated, "m1"},
+		{"store without token", "/merchant", "", "", `{"name":"pos","scopes":["orders:write"]}`, http.StatusUnauthorized, ""},
+		{"store key cannot issue keys", "/merchant", "m1", "k0", `{"name":"pos","scopes":["orders:write"]}`, http.StatusForbidden, ""},
+		{"store key needs a name", "/merchant", "m1", "", `{"scopes":["orders:write"]}`, http.StatusBadRequest, ""},
+		{"admin issues service key", "/admin", "", "", `{"name":"ops","scopes":["admin"]}`, http.StatusCreated, ""},
+		{"admin issues store key", "/admin", "", "", `{"name":"pos","scopes":["orders:write"],"merchant_id":"m2"}`, http.StatusCreated, "m2"},
+		{"admin names unknown store", "/admin", "", "", `{"name":"pos","scopes":["orders:write"],"merchant_id":"missing"}`, http.StatusNotFound, ""},
+		{"admin key cannot issue keys", "/admin", "", "k0", `{"name":"ops","scopes":["admin"]}`, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockAPIKeyService{}
+			h := NewAPIKeyHandler(svc)
+
+			r := gin.New()
+			r.POST("/merchant", withCaller(tt.merchantID, tt.apiKeyID), h.CreateMerchantKey)
+			r.POST("/admin", withCaller(tt.merchantID, tt.apiKeyID), h.CreateServiceKey)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				assert.Empty(t, svc.issued)
+				return
+			}
+			assert.Len(t, svc.issued, 1)
+			assert.Equal(t, tt.wantMerchant, svc.issued[0].MerchantID)
+		})
+	}
+}
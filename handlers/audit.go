@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Guldana11/gophermart/logging"
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService service.AuditServiceType
+}
+
+func NewAuditHandler(auditSvc service.AuditServiceType) *AuditHandler {
+	return &AuditHandler{auditService: auditSvc}
+}
+
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	filter := models.AuditFilter{
+		Action:  c.Query("action"),
+		Actor:   c.Query("actor"),
+		Subject: c.Query("subject"),
+		Outcome: c.Query("outcome"),
+	}
+
+	var details []models.FieldError
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			details = append(details, models.FieldError{Field: "before_id", Message: "must be an integer"})
+		}
+		filter.BeforeID = n
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			details = append(details, models.FieldError{Field: "limit", Message: "must be an integer"})
+		}
+		filter.Limit = n
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				details = append(details, models.FieldError{Field: p.name, Message: "must be an RFC 3339 timestamp"})
+				continue
+			}
+			*p.dst = &t
+		}
+	}
+	if len(details) > 0 {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, details...))
+		return
+	}
+
+	events, err := h.auditService.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// AuditAdminActions records every state-changing call on the admin API,
+// including the ones that were refused.
+func AuditAdminActions(recorder service.AuditRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+
+		var err error
+		if last := c.Errors.Last(); last != nil {
+			err = last.Err
+		}
+
+		event := auditEvent(c, models.AuditActionAdminPrefix+c.Request.Method+" "+c.FullPath(), err)
+		if err == nil && c.Writer.Status() >= http.StatusBadRequest {
+			event.Outcome = models.AuditOutcomeFailure
+			event.Reason = strconv.Itoa(c.Writer.Status())
+		}
+		event.Subject = c.Param("id")
+		recorder.Record(c.Request.Context(), event)
+	}
+}
+
+// auditEvent describes the current request for the audit trail. The outcome
+// follows err, whose envelope code becomes the failure reason.
+func auditEvent(c *gin.Context, action string, err error) models.AuditEvent {
+	event := models.AuditEvent{
+		Action:    action,
+		Outcome:   models.AuditOutcomeSuccess,
+		Actor:     actorFromContext(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		RequestID: logging.RequestID(c.Request.Context()),
+	}
+	if err != nil {
+		event.Outcome = models.AuditOutcomeFailure
+		event.Reason = middleware.ToAPIError(err).Code
+	}
+	return event
+}
+
+func recordAudit(c *gin.Context, recorder service.AuditRecorder, event models.AuditEvent) {
+	if recorder != nil {
+		recorder.Record(c.Request.Context(), event)
+	}
+}
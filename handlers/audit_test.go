@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingAudit struct {
+	events []models.AuditEvent
+}
+
+func (r *recordingAudit) Record(ctx context.Context, event models.AuditEvent) {
+	r.events = append(r.events, event)
+}
+
+type stubUserService struct {
+	user *models.User
+	err  error
+}
+
+func (s stubUserService) Register(ctx context.Context, login, password, referralCode string) (*models.User, error) {
+	return s.user, s.err
+}
+
+func (s stubUserService) Login(ctx context.Context, login, password string) (*models.User, error) {
+	return s.user, s.err
+}
+
+func TestLoginHandler_Audit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		svc      stubUserService
+		expected models.AuditEvent
+	}{
+		{
+			name: "success",
+			svc:  stubUserService{user: &models.User{ID: "user-1", Login: "alice"}},
+			expected: models.AuditEvent{
+				Action: models.AuditActionLogin, Outcome: models.AuditOutcomeSuccess, Actor: "user:user-1", Subject: "alice",
+			},
+		},
+		{
+			name: "wrong password",
+			svc:  stubUserService{err: service.ErrInvalidCredentials},
+			expected: models.AuditEvent{
+				Action: models.AuditActionLogin, Outcome: models.AuditOutcomeFailure, Actor: "anonymous", Subject: "alice",
+				Reason: "invalid_credentials",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &recordingAudit{}
+			r := gin.New()
+			r.Use(middleware.RequestID())
+			r.POST("/api/user/login", LoginHandler(tt.svc, audit))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login", strings.NewReader(`{"login":"alice","password":"hunter2"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "curl/8.0")
+			req.Header.Set(middleware.RequestIDHeader, "req-1")
+			req.RemoteAddr = "203.0.113.7:4711"
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			tt.expected.IP = "203.0.113.7"
+			tt.expected.UserAgent = "curl/8.0"
+			tt.expected.RequestID = "req-1"
+			assert.Equal(t, []models.AuditEvent{tt.expected}, audit.events)
+		})
+	}
+}
+
+func TestAuditAdminActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	audit := &recordingAudit{}
+	r := gin.New()
+	admin := r.Group("/api/admin")
+	admin.Use(AuditAdminActions(audit), func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+		c.Set("role", c.GetHeader("X-Test-Role"))
+	})
+	admin.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	admin.POST("/users/:id/block", middleware.RequireRole(models.RoleAdmin), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, tc := range []struct{ method, path, role string }{
+		{http.MethodGet, "/api/admin/users/u-2", models.RoleSupport},
+		{http.MethodPost, "/api/admin/users/u-2/block", models.RoleAdmin},
+		{http.MethodPost, "/api/admin/users/u-2/block", models.RoleSupport},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("X-Test-User", "u-1")
+		req.Header.Set("X-Test-Role", tc.role)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if assert.Len(t, audit.events, 2, "reads are not audited") {
+		for _, e := range audit.events {
+			assert.Equal(t, "admin.POST /api/admin/users/:id/block", e.Action)
+			assert.Equal(t, "user:u-1", e.Actor)
+			assert.Equal(t, "u-2", e.Subject)
+		}
+		assert.Equal(t, models.AuditOutcomeSuccess, audit.events[0].Outcome)
+		assert.Equal(t, models.AuditOutcomeFailure, audit.events[1].Outcome)
+		assert.Equal(t, middleware.CodeForbidden, audit.events[1].Reason)
+	}
+}
@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"log"
 	"net/http"
 	"strings"
 
+	"github.com/Guldana11/gophermart/middleware"
 	"github.com/Guldana11/gophermart/models"
 	"github.com/Guldana11/gophermart/service"
 	"github.com/gin-gonic/gin"
@@ -12,6 +12,8 @@ import (
 
 type UserHandler struct {
 	BalanceService service.BalanceServiceType
+	HoldService    service.HoldServiceType
+	Audit          service.AuditRecorder
 }
 
 func NewUserHandler(balanceSvc service.BalanceServiceType) *UserHandler {
@@ -21,32 +23,44 @@ func NewUserHandler(balanceSvc service.BalanceServiceType) *UserHandler {
 func (h *UserHandler) GetBalance(c *gin.Context) {
 	userID := c.GetString("userID")
 	if strings.TrimSpace(userID) == "" {
-		c.AbortWithStatus(http.StatusUnauthorized)
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
 		return
 	}
 
 	current, withdrawn, err := h.BalanceService.GetUserBalance(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		middleware.AbortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.BalanceResponse{
+	resp := models.BalanceResponse{
 		Current:   current,
 		Withdrawn: withdrawn,
-	})
+	}
+	if h.HoldService != nil {
+		held, err := h.HoldService.GetHeld(c.Request.Context(), userID)
+		if err != nil {
+			middleware.AbortWithError(c, err)
+			return
+		}
+		resp.Held = held
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *UserHandler) Withdraw(c *gin.Context) {
 	userID := c.GetString("userID")
 	if strings.TrimSpace(userID) == "" {
-		c.AbortWithStatus(http.StatusUnauthorized)
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
 		return
 	}
 
 	var req models.WithdrawRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.AbortWithStatus(http.StatusUnprocessableEntity)
+		apiErr := decodeError(err)
+		apiErr.Status = http.StatusUnprocessableEntity
+		middleware.AbortWithError(c, apiErr)
 		return
 	}
 
@@ -56,15 +70,14 @@ func (h *UserHandler) Withdraw(c *gin.Context) {
 		req.Order,
 		req.Sum,
 	)
+
+	event := auditEvent(c, models.AuditActionWithdraw, err)
+	event.Subject = req.Order
+	event.Details = map[string]any{"sum": req.Sum}
+	recordAudit(c, h.Audit, event)
+
 	if err != nil {
-		switch err {
-		case service.ErrInvalidOrder:
-			c.AbortWithStatus(http.StatusUnprocessableEntity)
-		case service.ErrInsufficientFunds:
-			c.AbortWithStatus(http.StatusPaymentRequired) // 402
-		default:
-			c.AbortWithStatus(http.StatusInternalServerError)
-		}
+		middleware.AbortWithError(c, err)
 		return
 	}
 
@@ -74,7 +87,7 @@ func (h *UserHandler) Withdraw(c *gin.Context) {
 func (h *UserHandler) GetWithdrawals(c *gin.Context) {
 	userID := c.GetString("userID")
 	if strings.TrimSpace(userID) == "" {
-		c.AbortWithStatus(http.StatusUnauthorized)
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
 		return
 	}
 
@@ -83,8 +96,7 @@ func (h *UserHandler) GetWithdrawals(c *gin.Context) {
 		userID,
 	)
 	if err != nil {
-		log.Printf("GetWithdrawals error, user=%s: %v", userID, err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		middleware.AbortWithError(c, err)
 		return
 	}
 
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -64,7 +65,7 @@ func TestGetBalanceHandler(t *testing.T) {
 				return 0, 0, errors.New("db error")
 			},
 			expectedCode: http.StatusInternalServerError,
-			expectedBody: `{"error":"internal server error"}`,
+			expectedBody: `{"error":{"code":"internal_error","message":"internal server error"}}`,
 		},
 		{
 			name:   "success",
@@ -111,18 +112,21 @@ func TestWithdraw(t *testing.T) {
 		body           string
 		mockWithdraw   func(ctx context.Context, userID, order string, sum float64) error
 		expectedStatus int
+		expectedError  string
 	}{
 		{
 			name:           "unauthorized",
 			userID:         "",
 			body:           `{}`,
 			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "unauthorized",
 		},
 		{
 			name:           "invalid json",
 			userID:         "user-1",
 			body:           `{invalid}`,
 			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "invalid_request",
 		},
 		{
 			name:   "invalid order",
@@ -132,6 +136,7 @@ func TestWithdraw(t *testing.T) {
 				return service.ErrInvalidOrder
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "invalid_order",
 		},
 		{
 			name:   "insufficient funds",
@@ -141,6 +146,7 @@ func TestWithdraw(t *testing.T) {
 				return service.ErrInsufficientFunds
 			},
 			expectedStatus: http.StatusPaymentRequired, // 402
+			expectedError:  "insufficient_funds",
 		},
 		{
 			name:   "internal error",
@@ -150,6 +156,7 @@ func TestWithdraw(t *testing.T) {
 				return errors.New("db error")
 			},
 			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "internal_error",
 		},
 		{
 			name:   "success",
@@ -190,7 +197,15 @@ func TestWithdraw(t *testing.T) {
 			h.Withdraw(c)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
-			assert.Empty(t, w.Body.String())
+			if tt.expectedError == "" {
+				assert.Empty(t, w.Body.String())
+				return
+			}
+
+			var resp models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedError, resp.Error.Code)
+			assert.NotEmpty(t, resp.Error.Message)
 		})
 	}
 }
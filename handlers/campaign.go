@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CampaignHandler struct {
+	campaignService service.CampaignServiceType
+}
+
+func NewCampaignHandler(campaignSvc service.CampaignServiceType) *CampaignHandler {
+	return &CampaignHandler{campaignService: campaignSvc}
+}
+
+func (h *CampaignHandler) CreateCampaign(c *gin.Context) {
+	var req models.CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+
+	campaign, err := h.campaignService.CreateCampaign(c.Request.Context(), req)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, campaign)
+}
+
+func (h *CampaignHandler) ListCampaigns(c *gin.Context) {
+	campaigns, err := h.campaignService.ListCampaigns(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, campaigns)
+}
+
+func (h *CampaignHandler) DisableCampaign(c *gin.Context) {
+	if err := h.campaignService.DisableCampaign(c.Request.Context(), c.Param("id")); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CampaignHandler) GetUserGrants(c *gin.Context) {
+	grants, err := h.campaignService.GetUserGrants(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+)
+
+// decodeError describes why a JSON body could not be decoded without echoing
+// decoder internals back to the client.
+func decodeError(err error) *models.APIError {
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &typeErr):
+		return middleware.ErrInvalidRequest("request body has a field of the wrong type",
+			models.FieldError{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()})
+	case errors.As(err, &maxBytesErr):
+		return middleware.ErrInvalidRequest("request body is too large")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return middleware.ErrInvalidRequest("request body has an unknown field",
+			models.FieldError{Field: field, Message: "unknown field"})
+	default:
+		return middleware.ErrInvalidRequest("request body must be valid JSON")
+	}
+}
+
+// validationError reports request fields that decoded fine but failed checks.
+func validationError(status int, details ...models.FieldError) *models.APIError {
+	return middleware.NewAPIError(status, middleware.CodeValidationFailed, "request validation failed", details...)
+}
+
+func requiredField(field string) models.FieldError {
+	return models.FieldError{Field: field, Message: "must not be empty"}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.Use(middleware.ErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	r.NoRoute(middleware.NotFound)
+	r.NoMethod(middleware.MethodNotAllowed)
+
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	r.GET("/attached", func(c *gin.Context) {
+		_ = c.Error(service.ErrHoldNotFound)
+	})
+	r.GET("/wrapped", func(c *gin.Context) {
+		middleware.AbortWithError(c, errors.Join(errors.New("context"), service.ErrInsufficientFunds))
+	})
+	r.GET("/kinded", func(c *gin.Context) {
+		middleware.AbortWithError(c, service.Wrap(service.KindUpstream, "accrual request failed", errors.New("dial tcp: refused")))
+	})
+	r.POST("/register", RegisterHandler(nil, nil))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedError  models.APIError
+	}{
+		{
+			name:           "panic",
+			method:         http.MethodGet,
+			path:           "/panic",
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  models.APIError{Code: "internal_error", Message: "internal server error"},
+		},
+		{
+			name:           "error attached without response",
+			method:         http.MethodGet,
+			path:           "/attached",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  models.APIError{Code: "hold_not_found", Message: "hold not found"},
+		},
+		{
+			name:           "wrapped service error",
+			method:         http.MethodGet,
+			path:           "/wrapped",
+			expectedStatus: http.StatusPaymentRequired,
+			expectedError:  models.APIError{Code: "insufficient_funds", Message: "not enough points on the balance"},
+		},
+		{
+			name:           "error answered by kind",
+			method:         http.MethodGet,
+			path:           "/kinded",
+			expectedStatus: http.StatusBadGateway,
+			expectedError:  models.APIError{Code: "upstream_error", Message: "a dependency failed, try again later"},
+		},
+		{
+			name:           "unknown route",
+			method:         http.MethodGet,
+			path:           "/nope",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  models.APIError{Code: "not_found", Message: "route not found"},
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodDelete,
+			path:           "/panic",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedError:  models.APIError{Code: "method_not_allowed", Message: "method not allowed"},
+		},
+		{
+			name:           "field details",
+			method:         http.MethodPost,
+			path:           "/register",
+			body:           `{"login":"","password":""}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: models.APIError{
+				Code:    "validation_failed",
+				Message: "request validation failed",
+				Details: []models.FieldError{
+					{Field: "login", Message: "must not be empty"},
+					{Field: "password", Message: "must not be empty"},
+				},
+			},
+		},
+		{
+			name:           "wrong field type",
+			method:         http.MethodPost,
+			path:           "/register",
+			body:           `{"login":123,"password":"pass"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: models.APIError{
+				Code:    "invalid_request",
+				Message: "request body has a field of the wrong type",
+				Details: []models.FieldError{{Field: "login", Message: "must be a string"}},
+			},
+		},
+		{
+			name:           "unknown field",
+			method:         http.MethodPost,
+			path:           "/register",
+			body:           `{"login":"user","password":"pass","x":1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError: models.APIError{
+				Code:    "invalid_request",
+				Message: "request body has an unknown field",
+				Details: []models.FieldError{{Field: "x", Message: "unknown field"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var resp models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedError, *resp.Error)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthCheck probes one dependency. A failing critical check makes the
+// instance not ready; other checks are only reported.
+type HealthCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+// healthStatus is "ok" or "fail" per check. Probe errors can carry hosts and
+// driver details, so they are logged rather than returned.
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+type readinessResponse struct {
+	Status string                  `json:"status"`
+	Checks map[string]healthStatus `json:"checks,omitempty"`
+}
+
+type HealthHandler struct {
+	checks       []HealthCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+	logger       *slog.Logger
+}
+
+// NewHealthHandler returns probes running checks, each bounded by timeout.
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, timeout: timeout, logger: slog.Default()}
+}
+
+func (h *HealthHandler) SetLogger(logger *slog.Logger) {
+	h.logger = logger
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop sending
+// traffic while in-flight requests drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live answers as long as the process can serve HTTP at all.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, healthStatus{Status: "ok"})
+}
+
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, readinessResponse{Status: "shutting_down"})
+		return
+	}
+
+	errs := make([]error, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+			defer cancel()
+
+			errs[i] = check.Check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	resp := readinessResponse{Status: "ready", Checks: make(map[string]healthStatus, len(h.checks))}
+	status := http.StatusOK
+	for i, check := range h.checks {
+		if errs[i] == nil {
+			resp.Checks[check.Name] = healthStatus{Status: "ok"}
+			continue
+		}
+		resp.Checks[check.Name] = healthStatus{Status: "fail"}
+		h.logger.WarnContext(c.Request.Context(), "health check failed",
+			"check", check.Name, "critical", check.Critical, "error", errs[i])
+		if check.Critical {
+			resp.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		} else if resp.Status == "ready" {
+			resp.Status = "degraded"
+		}
+	}
+	c.JSON(status, resp)
+}
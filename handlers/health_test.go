@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name           string
+		checks         []HealthCheck
+		shuttingDown   bool
+		expectedStatus int
+		expectedBody   readinessResponse
+	}{
+		{
+			name: "ready",
+			checks: []HealthCheck{
+				{Name: "database", Critical: true, Check: ok},
+				{Name: "accrual", Check: ok},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: readinessResponse{Status: "ready", Checks: map[string]healthStatus{
+				"database": {Status: "ok"},
+				"accrual":  {Status: "ok"},
+			}},
+		},
+		{
+			name: "critical dependency down",
+			checks: []HealthCheck{
+				{Name: "database", Critical: true, Check: failing},
+				{Name: "accrual", Check: ok},
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: readinessResponse{Status: "not_ready", Checks: map[string]healthStatus{
+				"database": {Status: "fail"},
+				"accrual":  {Status: "ok"},
+			}},
+		},
+		{
+			name: "optional dependency down",
+			checks: []HealthCheck{
+				{Name: "database", Critical: true, Check: ok},
+				{Name: "accrual", Check: failing},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: readinessResponse{Status: "degraded", Checks: map[string]healthStatus{
+				"database": {Status: "ok"},
+				"accrual":  {Status: "fail"},
+			}},
+		},
+		{
+			name:           "check times out",
+			checks:         []HealthCheck{{Name: "migrations", Critical: true, Check: hanging}},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: readinessResponse{Status: "not_ready", Checks: map[string]healthStatus{
+				"migrations": {Status: "fail"},
+			}},
+		},
+		{
+			name:           "shutting down",
+			checks:         []HealthCheck{{Name: "database", Critical: true, Check: ok}},
+			shuttingDown:   true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   readinessResponse{Status: "shutting_down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(20*time.Millisecond, tt.checks...)
+			if tt.shuttingDown {
+				h.SetShuttingDown()
+			}
+
+			r := gin.New()
+			r.GET("/healthz", h.Live)
+			r.GET("/readyz", h.Ready)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var body readinessResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedBody, body)
+
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestHealthHandler_LogsFailedChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", 0)
+	assert.NoError(t, err)
+
+	h := NewHealthHandler(20*time.Millisecond,
+		HealthCheck{Name: "database", Critical: true, Check: func(ctx context.Context) error { return nil }},
+		HealthCheck{Name: "accrual", Check: func(ctx context.Context) error { return errors.New("connection refused") }},
+	)
+	h.SetLogger(logger)
+
+	r := gin.New()
+	r.GET("/readyz", h.Ready)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.NotContains(t, w.Body.String(), "connection refused")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "health check failed", entry["msg"])
+	assert.Equal(t, "accrual", entry["check"])
+	assert.Equal(t, "connection refused", entry["error"])
+}
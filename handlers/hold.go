@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HoldHandler struct {
+	holdService service.HoldServiceType
+}
+
+func NewHoldHandler(holdSvc service.HoldServiceType) *HoldHandler {
+	return &HoldHandler{holdService: holdSvc}
+}
+
+func (h *HoldHandler) AuthorizeHold(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	var req models.HoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiErr := decodeError(err)
+		apiErr.Status = http.StatusUnprocessableEntity
+		middleware.AbortWithError(c, apiErr)
+		return
+	}
+
+	hold, err := h.holdService.Authorize(
+		c.Request.Context(),
+		userID,
+		req.Order,
+		req.Sum,
+		time.Duration(req.TTLSeconds)*time.Second,
+	)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+func (h *HoldHandler) CaptureHold(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	hold, err := h.holdService.Capture(c.Request.Context(), userID, strings.TrimSpace(c.Param("order")))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func (h *HoldHandler) VoidHold(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	hold, err := h.holdService.Void(c.Request.Context(), userID, strings.TrimSpace(c.Param("order")))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func (h *HoldHandler) GetHolds(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	holds, err := h.holdService.GetHolds(c.Request.Context(), userID)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if len(holds) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, holds)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockHoldService struct {
+	AuthorizeFunc func(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error)
+	CaptureFunc   func(ctx context.Context, userID, order string) (*models.PointHold, error)
+	VoidFunc      func(ctx context.Context, userID, order string) (*models.PointHold, error)
+	GetHeldFunc   func(ctx context.Context, userID string) (float64, error)
+	GetHoldsFunc  func(ctx context.Context, userID string) ([]models.PointHold, error)
+}
+
+func (m *MockHoldService) Authorize(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error) {
+	return m.AuthorizeFunc(ctx, userID, order, sum, ttl)
+}
+
+func (m *MockHoldService) Capture(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	return m.CaptureFunc(ctx, userID, order)
+}
+
+func (m *MockHoldService) Void(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	return m.VoidFunc(ctx, userID, order)
+}
+
+func (m *MockHoldService) GetHeld(ctx context.Context, userID string) (float64, error) {
+	return m.GetHeldFunc(ctx, userID)
+}
+
+func (m *MockHoldService) GetHolds(ctx context.Context, userID string) ([]models.PointHold, error) {
+	return m.GetHoldsFunc(ctx, userID)
+}
+
+func TestHoldHandler_AuthorizeHold(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         string
+		body           string
+		mockAuthorize  func(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error)
+		expectedStatus int
+	}{
+		{
+			name:           "unauthorized",
+			userID:         "",
+			body:           `{}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid json",
+			userID:         "user-1",
+			body:           `{invalid}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "non-positive sum",
+			userID: "user-1",
+			body:   `{"order":"12345678903","sum":0}`,
+			mockAuthorize: func(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error) {
+				return nil, service.ErrInvalidSum
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "insufficient funds",
+			userID: "user-1",
+			body:   `{"order":"12345678903","sum":1000}`,
+			mockAuthorize: func(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error) {
+				return nil, service.ErrInsufficientFunds
+			},
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name:   "internal error",
+			userID: "user-1",
+			body:   `{"order":"12345678903","sum":100}`,
+			mockAuthorize: func(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "success",
+			userID: "user-1",
+			body:   `{"order":"12345678903","sum":100,"ttl_seconds":60}`,
+			mockAuthorize: func(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error) {
+				assert.Equal(t, time.Minute, ttl)
+				return &models.PointHold{OrderNumber: order, Sum: sum, Status: models.HoldStatusAuthorized}, nil
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHoldHandler(&MockHoldService{AuthorizeFunc: tt.mockAuthorize})
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/holds", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c.Request = req
+			if tt.userID != "" {
+				c.Set("userID", tt.userID)
+			}
+
+			h.AuthorizeHold(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestHoldHandler_CaptureAndVoid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"success", nil, http.StatusOK},
+		{"not found", service.ErrHoldNotFound, http.StatusNotFound},
+		{"not active", service.ErrHoldNotActive, http.StatusConflict},
+		{"internal error", errors.New("db error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		settle := func(ctx context.Context, userID, order string) (*models.PointHold, error) {
+			if tt.err != nil {
+				return nil, tt.err
+			}
+			return &models.PointHold{OrderNumber: order}, nil
+		}
+		h := NewHoldHandler(&MockHoldService{CaptureFunc: settle, VoidFunc: settle})
+
+		for action, fn := range map[string]gin.HandlerFunc{"capture": h.CaptureHold, "void": h.VoidHold} {
+			t.Run(tt.name+" "+action, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest(http.MethodPost, "/api/user/balance/holds/12345678903/"+action, nil)
+				c.Params = gin.Params{{Key: "order", Value: "12345678903"}}
+				c.Set("userID", "user-1")
+
+				fn(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code)
+			})
+		}
+	}
+}
+
+func TestGetBalanceHandler_WithHolds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewUserHandler(&MockBalanceService{
+		GetUserBalanceFunc: func(ctx context.Context, userID string) (float64, float64, error) {
+			return 400, 42, nil
+		},
+	})
+	handler.HoldService = &MockHoldService{
+		GetHeldFunc: func(ctx context.Context, userID string) (float64, error) {
+			return 100, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/user/balance", nil)
+	c.Set("userID", "user-1")
+
+	handler.GetBalance(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"current":400,"withdrawn":42,"held":100}`, w.Body.String())
+}
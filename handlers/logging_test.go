@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Guldana11/gophermart/logging"
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", 0)
+	assert.NoError(t, err)
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(logger), middleware.ErrorHandler(logger))
+	r.GET("/api/user/balance", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), "user-1"))
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		wantID    string
+	}{
+		{name: "caller id kept", requestID: "abc-123", wantID: "abc-123"},
+		{name: "unsafe id replaced", requestID: "bad id\nforged=1"},
+		{name: "missing id generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/balance?token=secret", nil)
+			if tt.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, id)
+			} else {
+				assert.NotEmpty(t, id)
+				assert.NotEqual(t, tt.requestID, id)
+			}
+
+			var entry map[string]any
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, id, entry["request_id"])
+			assert.Equal(t, "user-1", entry["user_id"])
+			assert.Equal(t, "/api/user/balance", entry["path"])
+			assert.EqualValues(t, http.StatusNoContent, entry["status"])
+			assert.NotContains(t, buf.String(), "secret")
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type MerchantHandler struct {
+	merchantService service.MerchantServiceType
+}
+
+func NewMerchantHandler(merchantSvc service.MerchantServiceType) *MerchantHandler {
+	return &MerchantHandler{merchantService: merchantSvc}
+}
+
+func (h *MerchantHandler) RegisterPurchase(c *gin.Context) {
+	merchantID := c.GetString("merchantID")
+	if strings.TrimSpace(merchantID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	var req models.MerchantPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+
+	err := h.merchantService.RegisterPurchase(c.Request.Context(), merchantID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrAlreadyUploadedSelf) {
+			c.Status(http.StatusOK)
+			return
+		}
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockMerchantService struct {
+	RegisterPurchaseFunc func(ctx context.Context, merchantID string, req models.MerchantPurchaseRequest) error
+}
+
+func (m *MockMerchantService) AuthenticateMerchant(ctx context.Context, token string) (*models.Merchant, error) {
+	if token != "secret" {
+		return nil, service.ErrInvalidMerchantToken
+	}
+	return &models.Merchant{ID: "merchant-1"}, nil
+}
+
+func (m *MockMerchantService) RegisterPurchase(ctx context.Context, merchantID string, req models.MerchantPurchaseRequest) error {
+	return m.RegisterPurchaseFunc(ctx, merchantID, req)
+}
+
+func TestMerchantHandler_RegisterPurchase(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		token          string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"missing token", "", `{"order":"79927398713","login":"alice"}`, nil, http.StatusUnauthorized},
+		{"wrong token", "nope", `{"order":"79927398713","login":"alice"}`, nil, http.StatusUnauthorized},
+		{"invalid json", "secret", `{invalid}`, nil, http.StatusBadRequest},
+		{"accepted", "secret", `{"order":"79927398713","login":"alice"}`, nil, http.StatusAccepted},
+		{"no member", "secret", `{"order":"79927398713"}`, service.ErrInvalidMember, http.StatusBadRequest},
+		{"member not found", "secret", `{"order":"79927398713","card":"7000000000000006"}`, service.ErrMemberNotFound, http.StatusNotFound},
+		{"invalid order", "secret", `{"order":"1","login":"alice"}`, service.ErrInvalidOrder, http.StatusUnprocessableEntity},
+		{"already uploaded", "secret", `{"order":"79927398713","login":"alice"}`, service.ErrAlreadyUploadedSelf, http.StatusOK},
+		{"uploaded by other", "secret", `{"order":"79927398713","login":"alice"}`, service.ErrAlreadyUploadedOther, http.StatusConflict},
+		{"internal error", "secret", `{"order":"79927398713","login":"alice"}`, errors.New("db error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockMerchantService{
+				RegisterPurchaseFunc: func(ctx context.Context, merchantID string, req models.MerchantPurchaseRequest) error {
+					assert.Equal(t, "merchant-1", merchantID)
+					return tt.err
+				},
+			}
+			h := NewMerchantHandler(svc)
+
+			r := gin.New()
+			r.POST("/api/merchant/purchases", middleware.MerchantAuth(svc), h.RegisterPurchase)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/merchant/purchases", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
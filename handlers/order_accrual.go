@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/Guldana11/gophermart/middleware"
 	"github.com/Guldana11/gophermart/service"
 	"github.com/gin-gonic/gin"
 )
@@ -11,18 +13,16 @@ import (
 func (h *OrderHandler) GetOrderAccrual(c *gin.Context) {
 	orderNumber := strings.TrimSpace(c.Param("number"))
 	if orderNumber == "" {
-		c.Status(http.StatusUnprocessableEntity)
+		middleware.AbortWithError(c, service.ErrInvalidOrder)
 		return
 	}
 
 	resp, err := h.loyaltyService.GetOrderAccrual(c.Request.Context(), orderNumber)
 	if err != nil {
-		if err == service.ErrTooManyReq {
+		if errors.Is(err, service.ErrTooManyReq) {
 			c.Header("Retry-After", "60")
-			c.Status(http.StatusTooManyRequests)
-			return
 		}
-		c.Status(http.StatusInternalServerError)
+		middleware.AbortWithError(c, err)
 		return
 	}
 
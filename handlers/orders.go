@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Guldana11/gophermart/middleware"
 	"github.com/Guldana11/gophermart/service"
 	"github.com/gin-gonic/gin"
 )
@@ -26,58 +27,53 @@ func NewOrderHandler(orderSvc service.OrderService, loyaltySvc service.LoyaltySe
 func (h *OrderHandler) UploadOrderHandler(c *gin.Context) {
 	userID := c.GetString("userID")
 	if strings.TrimSpace(userID) == "" {
-		c.AbortWithStatus(http.StatusUnauthorized)
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
 		return
 	}
 
 	body, err := c.GetRawData()
 	if err != nil {
-		c.AbortWithStatus(http.StatusBadRequest)
+		middleware.AbortWithError(c, middleware.ErrInvalidRequest("cannot read request body"))
 		return
 	}
 
 	orderNumber := strings.TrimSpace(string(body))
 	if orderNumber == "" {
-		c.AbortWithStatus(http.StatusBadRequest)
+		middleware.AbortWithError(c, middleware.ErrInvalidRequest("request body must contain an order number"))
 		return
 	}
 
 	if !service.CheckLuhn(orderNumber) {
-		c.AbortWithStatus(http.StatusUnprocessableEntity)
+		middleware.AbortWithError(c, service.ErrInvalidOrder)
 		return
 	}
 
 	err = h.orderService.UploadOrder(c.Request.Context(), userID, orderNumber)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrInvalidOrder):
-			c.AbortWithStatus(http.StatusUnprocessableEntity)
-			return
-		case errors.Is(err, service.ErrAlreadyUploadedSelf):
+		if errors.Is(err, service.ErrAlreadyUploadedSelf) {
 			c.Status(http.StatusOK)
 			return
-		case errors.Is(err, service.ErrAlreadyUploadedOther):
-			c.Status(http.StatusConflict)
-			return
-		default:
-			c.AbortWithStatus(http.StatusInternalServerError)
-			return
 		}
+		middleware.AbortWithError(c, err)
+		return
 	}
 
 	c.Status(http.StatusAccepted)
 }
 
+// GetOrdersHandler lists the member's orders as stored. The accrual worker
+// keeps their status, accrual and bonus up to date, so the list never waits
+// on the accrual system.
 func (h *OrderHandler) GetOrdersHandler(c *gin.Context) {
 	userID := c.GetString("userID")
 	if strings.TrimSpace(userID) == "" {
-		c.AbortWithStatus(http.StatusUnauthorized)
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
 		return
 	}
 
 	orders, err := h.orderService.GetOrders(c.Request.Context(), userID)
 	if err != nil {
-		c.AbortWithStatus(http.StatusInternalServerError)
+		middleware.AbortWithError(c, err)
 		return
 	}
 
@@ -92,24 +88,16 @@ func (h *OrderHandler) GetOrdersHandler(c *gin.Context) {
 
 	var result []map[string]interface{}
 	for _, order := range orders {
-		status := "NEW"
-		accrual := 0.0
-
-		if h.loyaltyService != nil {
-			resp, err := h.loyaltyService.GetOrderAccrual(c.Request.Context(), order.Number)
-			if err == nil && resp != nil {
-				status = resp.Status
-				accrual = resp.Accrual
-			}
-		}
-
 		orderMap := map[string]interface{}{
 			"number":      order.Number,
-			"status":      status,
+			"status":      order.Status,
 			"uploaded_at": order.UploadedAt.Format(time.RFC3339),
 		}
-		if accrual > 0 {
-			orderMap["accrual"] = accrual
+		if order.Accrual > 0 {
+			orderMap["accrual"] = order.Accrual
+		}
+		if order.Bonus > 0 {
+			orderMap["bonus"] = order.Bonus
 		}
 		result = append(result, orderMap)
 	}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Guldana11/gophermart/models"
 	"github.com/Guldana11/gophermart/service"
@@ -96,6 +97,38 @@ func TestOrderHandler_GetOrdersHandler(t *testing.T) {
 	}
 }
 
+type unreachableLoyalty struct{ t *testing.T }
+
+func (l unreachableLoyalty) GetOrderAccrual(ctx context.Context, orderNumber string) (*models.OrderAccrualResponse, error) {
+	l.t.Errorf("GetOrderAccrual(%s) called while listing orders", orderNumber)
+	return nil, errors.New("unexpected call")
+}
+
+func TestOrderHandler_GetOrdersHandler_StoredState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	uploaded := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	orderSvc := &MockOrderService{GetOrdersFunc: func(ctx context.Context, userID string) ([]models.Order, error) {
+		return []models.Order{
+			{Number: "12345678903", Status: models.OrderStatusProcessing, UploadedAt: uploaded},
+			{Number: "79927398713", Status: models.OrderStatusProcessed, Accrual: 500, Bonus: 25, UploadedAt: uploaded.Add(time.Hour)},
+		}, nil
+	}}
+	h := NewOrderHandler(orderSvc, unreachableLoyalty{t})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+	c.Set("userID", "user1")
+	h.GetOrdersHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+		{"number":"79927398713","status":"PROCESSED","accrual":500,"bonus":25,"uploaded_at":"2026-05-01T13:00:00Z"},
+		{"number":"12345678903","status":"PROCESSING","uploaded_at":"2026-05-01T12:00:00Z"}
+	]`, w.Body.String())
+}
+
 func TestOrderHandler_UploadOrderHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
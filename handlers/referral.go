@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReferralHandler struct {
+	referralService service.ReferralServiceType
+}
+
+func NewReferralHandler(referralSvc service.ReferralServiceType) *ReferralHandler {
+	return &ReferralHandler{referralService: referralSvc}
+}
+
+func (h *ReferralHandler) ListReferrals(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	referrals, err := h.referralService.ListReferrals(c.Request.Context(), userID)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if len(referrals) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, referrals)
+}
@@ -10,10 +10,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterHandler(svc service.UserServiceInterface) gin.HandlerFunc {
+// RegisterHandler signs members up. audit may be nil.
+func RegisterHandler(svc service.UserServiceInterface, audit service.AuditRecorder) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.ContentType() != "application/json" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid content type"})
+			middleware.AbortWithError(c, middleware.ErrInvalidRequest("content type must be application/json"))
 			return
 		}
 
@@ -23,57 +24,82 @@ func RegisterHandler(svc service.UserServiceInterface) gin.HandlerFunc {
 		decoder := json.NewDecoder(c.Request.Body)
 		decoder.DisallowUnknownFields()
 		if err := decoder.Decode(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			middleware.AbortWithError(c, decodeError(err))
 			return
 		}
 
-		if req.Login == "" || req.Password == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "login and password cannot be empty"})
+		if details := credentialErrors(req); len(details) > 0 {
+			middleware.AbortWithError(c, validationError(http.StatusBadRequest, details...))
 			return
 		}
 
-		user, err := svc.Register(c.Request.Context(), req.Login, req.Password)
+		user, err := svc.Register(c.Request.Context(), req.Login, req.Password, req.ReferralCode)
 		if err != nil {
-			if err.Error() == "login already exists" {
-				c.JSON(http.StatusConflict, gin.H{"error": "login already taken"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+			recordAudit(c, audit, credentialEvent(c, models.AuditActionRegister, req.Login, nil, err))
+			middleware.AbortWithError(c, err)
 			return
 		}
 
-		token, err := middleware.GenerateJWT(user.ID)
+		token, err := middleware.GenerateJWT(user.ID, user.Role)
+		recordAudit(c, audit, credentialEvent(c, models.AuditActionRegister, req.Login, user, err))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot generate token"})
+			middleware.AbortWithError(c, err)
 			return
 		}
 
 		c.SetCookie("access_token", token, 3600*24, "/", "", false, true)
-		c.JSON(http.StatusOK, gin.H{"id": user.ID, "login": user.Login})
+		c.JSON(http.StatusOK, gin.H{"id": user.ID, "login": user.Login, "loyalty_card": user.LoyaltyCard, "referral_code": user.ReferralCode})
 	}
 }
 
-func LoginHandler(svc service.UserServiceInterface) gin.HandlerFunc {
+// LoginHandler starts a session. Every attempt, failed or not, is audited
+// when audit is set.
+func LoginHandler(svc service.UserServiceInterface, audit service.AuditRecorder) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req models.RegisterRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			middleware.AbortWithError(c, decodeError(err))
 			return
 		}
 
 		user, err := svc.Login(c.Request.Context(), req.Login, req.Password)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid login or password"})
+			recordAudit(c, audit, credentialEvent(c, models.AuditActionLogin, req.Login, nil, err))
+			middleware.AbortWithError(c, err)
 			return
 		}
 
-		token, err := middleware.GenerateJWT(user.ID)
+		token, err := middleware.GenerateJWT(user.ID, user.Role)
+		recordAudit(c, audit, credentialEvent(c, models.AuditActionLogin, req.Login, user, err))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot generate token"})
+			middleware.AbortWithError(c, err)
 			return
 		}
 
 		c.SetCookie("access_token", token, 3600*24, "/", "", false, true)
-		c.JSON(http.StatusOK, gin.H{"id": user.ID, "login": user.Login})
+		c.JSON(http.StatusOK, gin.H{"id": user.ID, "login": user.Login, "loyalty_card": user.LoyaltyCard, "referral_code": user.ReferralCode})
 	}
 }
+
+func credentialErrors(req models.RegisterRequest) []models.FieldError {
+	var details []models.FieldError
+	if req.Login == "" {
+		details = append(details, requiredField("login"))
+	}
+	if req.Password == "" {
+		details = append(details, requiredField("password"))
+	}
+	return details
+}
+
+// credentialEvent audits a sign-up or login for login. Before a session
+// exists the caller is anonymous; once user is known it becomes the actor.
+func credentialEvent(c *gin.Context, action, login string, user *models.User, err error) models.AuditEvent {
+	event := auditEvent(c, action, err)
+	event.Actor = "anonymous"
+	if user != nil {
+		event.Actor = "user:" + user.ID
+	}
+	event.Subject = login
+	return event
+}
@@ -11,17 +11,18 @@ import (
 
 	"github.com/Guldana11/gophermart/handlers"
 	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockUserService struct {
-	RegisterFunc func(ctx context.Context, login, password string) (*models.User, error)
+	RegisterFunc func(ctx context.Context, login, password, referralCode string) (*models.User, error)
 	LoginFunc    func(ctx context.Context, login, password string) (*models.User, error)
 }
 
-func (m *mockUserService) Register(ctx context.Context, login, password string) (*models.User, error) {
-	return m.RegisterFunc(ctx, login, password)
+func (m *mockUserService) Register(ctx context.Context, login, password, referralCode string) (*models.User, error) {
+	return m.RegisterFunc(ctx, login, password, referralCode)
 }
 
 func (m *mockUserService) Login(ctx context.Context, login, password string) (*models.User, error) {
@@ -118,7 +119,7 @@ func TestRegisterHandler(t *testing.T) {
 			body:        `{"login":"user","password":"pass"}`,
 			contentType: "application/json",
 			mockRegister: func(ctx context.Context, login, password string) (*models.User, error) {
-				return nil, errors.New("login already exists")
+				return nil, service.ErrLoginTaken
 			},
 			expectedStatus: http.StatusConflict,
 		},
@@ -136,7 +137,7 @@ func TestRegisterHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockSvc := &mockUserService{
-				RegisterFunc: func(ctx context.Context, login, password string) (*models.User, error) {
+				RegisterFunc: func(ctx context.Context, login, password, referralCode string) (*models.User, error) {
 					if tt.mockRegister != nil {
 						return tt.mockRegister(ctx, login, password)
 					}
@@ -145,7 +146,7 @@ func TestRegisterHandler(t *testing.T) {
 			}
 
 			r := gin.New()
-			r.POST("/api/user/register", handlers.RegisterHandler(mockSvc))
+			r.POST("/api/user/register", handlers.RegisterHandler(mockSvc, nil))
 
 			req := httptest.NewRequest("POST", "/api/user/register", bytes.NewBufferString(tt.body))
 			req.Header.Set("Content-Type", tt.contentType)
@@ -182,7 +183,7 @@ func TestLoginHandler(t *testing.T) {
 			body:        `{"login":"user","password":"pass"}`,
 			contentType: "application/json",
 			mockLogin: func(ctx context.Context, login, password string) (*models.User, error) {
-				return nil, errors.New("invalid login or password")
+				return nil, service.ErrInvalidCredentials
 			},
 			expectedStatus: http.StatusUnauthorized,
 		},
@@ -259,7 +260,7 @@ func TestLoginHandler(t *testing.T) {
 			mockLogin: func(ctx context.Context, login, password string) (*models.User, error) {
 				return nil, errors.New("db error")
 			},
-			expectedStatus: http.StatusUnauthorized,
+			expectedStatus: http.StatusInternalServerError,
 		},
 	}
 
@@ -275,7 +276,7 @@ func TestLoginHandler(t *testing.T) {
 			}
 
 			r := gin.New()
-			r.POST("/api/user/login", handlers.LoginHandler(mockSvc))
+			r.POST("/api/user/login", handlers.LoginHandler(mockSvc, nil))
 
 			req := httptest.NewRequest("POST", "/api/user/login", bytes.NewBufferString(tt.body))
 			req.Header.Set("Content-Type", tt.contentType)
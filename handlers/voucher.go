@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type VoucherHandler struct {
+	voucherService service.VoucherServiceType
+}
+
+func NewVoucherHandler(voucherSvc service.VoucherServiceType) *VoucherHandler {
+	return &VoucherHandler{voucherService: voucherSvc}
+}
+
+func (h *VoucherHandler) CreateBatch(c *gin.Context) {
+	var req models.CreateVoucherBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+
+	batch, err := h.voucherService.CreateBatch(c.Request.Context(), actorFromContext(c), req)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, batch)
+}
+
+func (h *VoucherHandler) Report(c *gin.Context) {
+	reports, err := h.voucherService.Report(c.Request.Context())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+func (h *VoucherHandler) Redeem(c *gin.Context) {
+	userID := c.GetString("userID")
+	if strings.TrimSpace(userID) == "" {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	var req models.RedeemVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, requiredField("code")))
+		return
+	}
+
+	redemption, err := h.voucherService.Redeem(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, redemption)
+}
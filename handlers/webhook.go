@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Guldana11/gophermart/middleware"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler serves the same routes to members and merchants; the
+// authenticated caller owns the endpoints it manages.
+type WebhookHandler struct {
+	webhookService service.WebhookServiceType
+}
+
+func NewWebhookHandler(webhookSvc service.WebhookServiceType) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookSvc}
+}
+
+// webhookOwner is the merchant on merchant routes and the member otherwise.
+func webhookOwner(c *gin.Context) (models.WebhookOwner, bool) {
+	if id := c.GetString("merchantID"); strings.TrimSpace(id) != "" {
+		return models.WebhookOwner{MerchantID: id}, true
+	}
+	if id := c.GetString("userID"); strings.TrimSpace(id) != "" {
+		return models.WebhookOwner{UserID: id}, true
+	}
+	return models.WebhookOwner{}, false
+}
+
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	owner, ok := webhookOwner(c)
+	if !ok {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, decodeError(err))
+		return
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, requiredField("url")))
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), owner, req)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	owner, ok := webhookOwner(c)
+	if !ok {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(c.Request.Context(), owner)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if len(endpoints) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoints)
+}
+
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	owner, ok := webhookOwner(c)
+	if !ok {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request.Context(), owner, c.Param("id")); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) EnableEndpoint(c *gin.Context) {
+	owner, ok := webhookOwner(c)
+	if !ok {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	if err := h.webhookService.EnableEndpoint(c.Request.Context(), owner, c.Param("id")); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	owner, ok := webhookOwner(c)
+	if !ok {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	var beforeID int64
+	var limit int
+	var details []models.FieldError
+	if v := c.Query("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			details = append(details, models.FieldError{Field: "before_id", Message: "must be a non-negative integer"})
+		}
+		beforeID = n
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			details = append(details, models.FieldError{Field: "limit", Message: "must be a non-negative integer"})
+		}
+		limit = n
+	}
+	if len(details) > 0 {
+		middleware.AbortWithError(c, validationError(http.StatusBadRequest, details...))
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), owner, c.Param("id"), beforeID, limit)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	owner, ok := webhookOwner(c)
+	if !ok {
+		middleware.AbortWithError(c, middleware.ErrUnauthorized())
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("delivery"), 10, 64)
+	if err != nil {
+		middleware.AbortWithError(c, service.ErrDeliveryNotFound)
+		return
+	}
+
+	if err := h.webhookService.ReplayDelivery(c.Request.Context(), owner, c.Param("id"), id); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
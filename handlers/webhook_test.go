@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockWebhookService struct {
+	owners []models.WebhookOwner
+	replay func(endpointID string, id int64) error
+}
+
+func (m *MockWebhookService) CreateEndpoint(ctx context.Context, owner models.WebhookOwner, req models.CreateWebhookRequest) (*models.CreatedWebhookEndpoint, error) {
+	m.owners = append(m.owners, owner)
+	if req.URL == "ftp://example.com" {
+		return nil, service.ErrInvalidWebhook
+	}
+	return &models.CreatedWebhookEndpoint{WebhookEndpoint: models.WebhookEndpoint{ID: "w1", URL: req.URL}, Secret: "whsec_x"}, nil
+}
+
+func (m *MockWebhookService) ListEndpoints(ctx context.Context, owner models.WebhookOwner) ([]models.WebhookEndpoint, error) {
+	m.owners = append(m.owners, owner)
+	return nil, nil
+}
+
+func (m *MockWebhookService) DeleteEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error {
+	return nil
+}
+
+func (m *MockWebhookService) EnableEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error {
+	return service.ErrWebhookNotFound
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, owner models.WebhookOwner, endpointID string, beforeID int64, limit int) ([]models.WebhookDelivery, error) {
+	return []models.WebhookDelivery{}, nil
+}
+
+func (m *MockWebhookService) ReplayDelivery(ctx context.Context, owner models.WebhookOwner, endpointID string, id int64) error {
+	return m.replay(endpointID, id)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &MockWebhookService{replay: func(endpointID string, id int64) error {
+		assert.Equal(t, "w1", endpointID)
+		assert.Equal(t, int64(7), id)
+		return nil
+	}}
+	h := NewWebhookHandler(svc)
+
+	tests := []struct {
+		name           string
+		userID         string
+		merchantID     string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"unauthorized", "", "", http.MethodGet, "/webhooks", "", http.StatusUnauthorized},
+		{"create for member", "user-1", "", http.MethodPost, "/webhooks", `{"url":"https://example.com"}`, http.StatusCreated},
+		{"create for merchant", "", "merchant-1", http.MethodPost, "/webhooks", `{"url":"https://example.com"}`, http.StatusCreated},
+		{"missing url", "user-1", "", http.MethodPost, "/webhooks", `{}`, http.StatusBadRequest},
+		{"invalid url", "user-1", "", http.MethodPost, "/webhooks", `{"url":"ftp://example.com"}`, http.StatusUnprocessableEntity},
+		{"empty list", "user-1", "", http.MethodGet, "/webhooks", "", http.StatusNoContent},
+		{"enable unknown", "user-1", "", http.MethodPost, "/webhooks/w2/enable", "", http.StatusNotFound},
+		{"deliveries", "user-1", "", http.MethodGet, "/webhooks/w1/deliveries?limit=10", "", http.StatusOK},
+		{"negative limit", "user-1", "", http.MethodGet, "/webhooks/w1/deliveries?limit=-1", "", http.StatusBadRequest},
+		{"replay", "user-1", "", http.MethodPost, "/webhooks/w1/deliveries/7/replay", "", http.StatusAccepted},
+		{"replay bad id", "user-1", "", http.MethodPost, "/webhooks/w1/deliveries/x/replay", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set("userID", tt.userID)
+				}
+				if tt.merchantID != "" {
+					c.Set("merchantID", tt.merchantID)
+				}
+			})
+			r.POST("/webhooks", h.CreateEndpoint)
+			r.GET("/webhooks", h.ListEndpoints)
+			r.POST("/webhooks/:id/enable", h.EnableEndpoint)
+			r.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+			r.POST("/webhooks/:id/deliveries/:delivery/replay", h.ReplayDelivery)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	assert.Contains(t, svc.owners, models.WebhookOwner{UserID: "user-1"})
+	assert.Contains(t, svc.owners, models.WebhookOwner{MerchantID: "merchant-1"})
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_ContextAndRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", slog.LevelInfo)
+	assert.NoError(t, err)
+
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), "user-1")
+	logger.InfoContext(ctx, "login", "login", "alice", "password", "hunter2", "access_token", "jwt", "voucher_code", "ABCD")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "user-1", entry["user_id"])
+	assert.Equal(t, "alice", entry["login"])
+	assert.Equal(t, "[REDACTED]", entry["password"])
+	assert.Equal(t, "[REDACTED]", entry["access_token"])
+	assert.Equal(t, "[REDACTED]", entry["voucher_code"])
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestFromEnv(t *testing.T) {
+	env := func(vals map[string]string) func(string) string {
+		return func(k string) string { return vals[k] }
+	}
+
+	var buf bytes.Buffer
+	logger, err := FromEnv(&buf, env(map[string]string{"LOG_LEVEL": "warn"}))
+	assert.NoError(t, err)
+	logger.Info("dropped")
+	assert.Empty(t, buf.String())
+
+	_, err = FromEnv(&buf, env(map[string]string{"LOG_FORMAT": "xml"}))
+	assert.Error(t, err)
+	_, err = FromEnv(&buf, env(map[string]string{"LOG_LEVEL": "loud"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+// Package memory keeps users, orders and balances in process memory. It
+// mirrors the Postgres repositories closely enough to run the service locally
+// and to test against without a database; nothing survives a restart.
+package memory
+
+import (
+	"math"
+	"sync"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+)
+
+// DB is the shared state the repositories work on, the in-memory counterpart
+// of the connection pool. One mutex guards everything, which also makes every
+// repository call atomic.
+type DB struct {
+	mu sync.Mutex
+
+	users       map[string]models.User
+	userIDs     map[string]string // login -> user ID
+	points      map[string]*balance
+	withdrawals map[string][]models.Withdrawal // user ID -> oldest first
+	withdrawn   map[string]bool                // order numbers
+
+	orders      map[string]*storedOrder
+	lastOrderID int
+}
+
+type balance struct {
+	current   float64
+	withdrawn float64
+}
+
+// roundCents keeps amounts to the cent, as NUMERIC(12,2) columns do, so that
+// balances do not drift as they are credited and debited.
+func roundCents(points float64) float64 {
+	return math.Round(points*100) / 100
+}
+
+type storedOrder struct {
+	order models.Order
+	seq   int
+}
+
+func New() *DB {
+	return &DB{
+		users:       make(map[string]models.User),
+		userIDs:     make(map[string]string),
+		points:      make(map[string]*balance),
+		withdrawals: make(map[string][]models.Withdrawal),
+		withdrawn:   make(map[string]bool),
+		orders:      make(map[string]*storedOrder),
+	}
+}
+
+// errCheckViolation stands in for the Postgres check constraints, which the
+// database package reports as validation errors.
+func errCheckViolation(constraint string) error {
+	return service.Wrap(service.KindValidation, "value rejected by a check constraint", &constraintError{constraint})
+}
+
+type constraintError struct{ name string }
+
+func (e *constraintError) Error() string {
+	return "violates check constraint " + e.name
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/Guldana11/gophermart/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+		db := New()
+		return repositorytest.Backend{Users: NewUserRepo(db), Orders: NewOrderRepo(db)}
+	})
+}
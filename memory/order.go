@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+)
+
+var _ repository.OrderRepository = (*OrderRepo)(nil)
+
+type OrderRepo struct {
+	db *DB
+}
+
+func NewOrderRepo(db *DB) *OrderRepo {
+	return &OrderRepo{db: db}
+}
+
+func (r *OrderRepo) CheckOrderExists(ctx context.Context, orderNumber string) (string, bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	o, ok := r.db.orders[orderNumber]
+	if !ok {
+		return "", false, nil
+	}
+	return o.order.UserID, true, nil
+}
+
+func (r *OrderRepo) CreateOrder(ctx context.Context, order models.Order) error {
+	if order.Number == "" || strings.Trim(order.Number, "0123456789") != "" {
+		return errCheckViolation("chk_number_digits")
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.orders[order.Number]; ok {
+		return service.ErrAlreadyUploadedOther
+	}
+	if _, ok := r.db.users[order.UserID]; !ok {
+		return service.ErrInvalidReference
+	}
+
+	r.db.lastOrderID++
+	r.db.orders[order.Number] = &storedOrder{
+		order: models.Order{
+			ID:         r.db.lastOrderID,
+			Number:     order.Number,
+			UserID:     order.UserID,
+			Status:     models.OrderStatusNew,
+			UploadedAt: time.Now(),
+		},
+		seq: r.db.lastOrderID,
+	}
+	return nil
+}
+
+// GetOrdersByUser returns the member's orders newest first. Like the Postgres
+// repository it fills in only the fields the order list shows.
+func (r *OrderRepo) GetOrdersByUser(ctx context.Context, userID string) ([]models.Order, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var stored []*storedOrder
+	for _, o := range r.db.orders {
+		if o.order.UserID == userID {
+			stored = append(stored, o)
+		}
+	}
+	slices.SortFunc(stored, func(a, b *storedOrder) int {
+		return cmp.Compare(b.seq, a.seq)
+	})
+
+	var orders []models.Order
+	for _, o := range stored {
+		orders = append(orders, models.Order{
+			Number:     o.order.Number,
+			Status:     o.order.Status,
+			Accrual:    o.order.Accrual,
+			Bonus:      o.order.Bonus,
+			UploadedAt: o.order.UploadedAt,
+		})
+	}
+	return orders, nil
+}
+
+func (r *OrderRepo) GetOrder(ctx context.Context, orderNumber string) (*models.Order, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	o, ok := r.db.orders[orderNumber]
+	if !ok {
+		return nil, service.ErrOrderNotFound
+	}
+	order := o.order
+	return &order, nil
+}
+
+func (r *OrderRepo) GetPendingOrders(ctx context.Context, after string, limit int) ([]models.Order, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var orders []models.Order
+	for _, o := range r.db.orders {
+		pending := o.order.Status == models.OrderStatusNew || o.order.Status == models.OrderStatusProcessing
+		if pending && o.order.Number > after {
+			orders = append(orders, o.order)
+		}
+	}
+	slices.SortFunc(orders, func(a, b models.Order) int {
+		return cmp.Compare(a.Number, b.Number)
+	})
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+// UpdateOrderAccrual stores the verdict and corrects the member's balance by
+// the change in credited accrual plus bonus, the same way the Postgres
+// repository does.
+func (r *OrderRepo) UpdateOrderAccrual(ctx context.Context, orderNumber, status string, accrual, bonus float64) error {
+	switch status {
+	case models.OrderStatusNew, models.OrderStatusProcessing, models.OrderStatusInvalid, models.OrderStatusProcessed:
+	default:
+		return errCheckViolation("chk_orders_status")
+	}
+	if accrual < 0 || bonus < 0 {
+		return errCheckViolation("chk_orders_amounts")
+	}
+
+	accrual, bonus = roundCents(accrual), roundCents(bonus)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	o, ok := r.db.orders[orderNumber]
+	if !ok {
+		return service.ErrOrderNotFound
+	}
+
+	delta := creditedAccrual(status, accrual+bonus) - creditedAccrual(o.order.Status, o.order.Accrual+o.order.Bonus)
+	o.order.Status = status
+	o.order.Accrual = accrual
+	o.order.Bonus = bonus
+
+	if delta != 0 {
+		b, ok := r.db.points[o.order.UserID]
+		if !ok {
+			b = &balance{}
+			r.db.points[o.order.UserID] = b
+		}
+		b.current = roundCents(b.current + delta)
+	}
+	return nil
+}
+
+func creditedAccrual(status string, accrual float64) float64 {
+	if status != models.OrderStatusProcessed {
+		return 0
+	}
+	return accrual
+}
+
+// CountOrdersByStatus reports how many orders are in each status. Statuses
+// without orders are left out.
+func (r *OrderRepo) CountOrdersByStatus(ctx context.Context) (map[string]int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, o := range r.db.orders {
+		counts[o.order.Status]++
+	}
+	return counts, nil
+}
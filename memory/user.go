@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ repository.UserRepository = (*UserRepo)(nil)
+
+type UserRepo struct {
+	db *DB
+}
+
+func NewUserRepo(db *DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+func (r *UserRepo) CreateUser(ctx context.Context, login, password string) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := service.NewLoyaltyCardNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	referralCode, err := service.NewReferralCode()
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		ID:           uuid.New().String(),
+		Login:        login,
+		PasswordHash: string(hash),
+		LoyaltyCard:  card,
+		ReferralCode: referralCode,
+		Role:         models.RoleUser,
+		CreatedAt:    time.Now(),
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, taken := r.db.userIDs[login]; taken {
+		return nil, service.ErrLoginTaken
+	}
+	r.db.users[user.ID] = user
+	r.db.userIDs[login] = user.ID
+	r.db.points[user.ID] = &balance{}
+	return &user, nil
+}
+
+func (r *UserRepo) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	id, ok := r.db.userIDs[login]
+	if !ok {
+		return nil, service.ErrUserNotFound
+	}
+	user := r.db.users[id]
+	return &user, nil
+}
+
+func (r *UserRepo) GetUserPoints(ctx context.Context, userID string) (float64, float64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	b, ok := r.db.points[userID]
+	if !ok {
+		return 0, 0, nil
+	}
+	return b.current, b.withdrawn, nil
+}
+
+func (r *UserRepo) Withdraw(ctx context.Context, userID string, order string, sum float64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.withdrawn[order] {
+		return service.ErrInvalidOrder
+	}
+	b, ok := r.db.points[userID]
+	if !ok {
+		return service.ErrInvalidReference
+	}
+	sum = roundCents(sum)
+	if sum > b.current {
+		return service.ErrInsufficientFunds
+	}
+	if sum <= 0 {
+		return errCheckViolation("chk_withdrawals_sum")
+	}
+
+	b.current = roundCents(b.current - sum)
+	b.withdrawn = roundCents(b.withdrawn + sum)
+	r.db.withdrawn[order] = true
+	r.db.withdrawals[userID] = append(r.db.withdrawals[userID], models.Withdrawal{
+		OrderNumber: order,
+		Sum:         sum,
+		ProcessedAt: time.Now(),
+	})
+	return nil
+}
+
+func (r *UserRepo) GetUserWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	withdrawals := slices.Clone(r.db.withdrawals[userID])
+	slices.Reverse(withdrawals)
+	if withdrawals == nil {
+		withdrawals = make([]models.Withdrawal, 0)
+	}
+	return withdrawals, nil
+}
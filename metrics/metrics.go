@@ -0,0 +1,190 @@
+// Package metrics exposes the service's Prometheus metrics. Everything is
+// registered on a private registry so tests can read values back without a
+// Prometheus server.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophermart"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	accrualCalls    *prometheus.CounterVec
+	accrualDuration *prometheus.HistogramVec
+	withdrawals     *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests answered, by route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent answering HTTP requests, by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		accrualCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "accrual_requests_total",
+			Help:      "Calls to the accrual system, by outcome.",
+		}, []string{"outcome"}),
+		accrualDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "accrual_request_duration_seconds",
+			Help:      "Latency of calls to the accrual system, by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		withdrawals: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "withdrawals_total",
+			Help:      "Withdrawal attempts, by result and failure reason.",
+		}, []string{"result", "reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.accrualCalls,
+		m.accrualDuration,
+		m.withdrawals,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry returns the registry the metrics are collected on.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// ObserveAccrualCall records a call to the accrual system. outcome is the
+// answered status ("200", "204", "404", "429", "5xx") or "error" when no answer
+// arrived.
+func (m *Metrics) ObserveAccrualCall(outcome string, elapsed time.Duration) {
+	m.accrualCalls.WithLabelValues(outcome).Inc()
+	m.accrualDuration.WithLabelValues(outcome).Observe(elapsed.Seconds())
+}
+
+// ObserveWithdrawal records a withdrawal attempt. An empty reason means the
+// withdrawal went through.
+func (m *Metrics) ObserveWithdrawal(reason string) {
+	if reason == "" {
+		m.withdrawals.WithLabelValues("success", "").Inc()
+		return
+	}
+	m.withdrawals.WithLabelValues("failure", reason).Inc()
+}
+
+// PoolStater is implemented by *pgxpool.Pool.
+type PoolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// RegisterPool exports the connection pool's statistics on every scrape.
+func (m *Metrics) RegisterPool(pool PoolStater) {
+	m.registry.MustRegister(&poolCollector{pool: pool})
+}
+
+// OrderCounter reports how many orders are in each status.
+type OrderCounter interface {
+	CountOrdersByStatus(ctx context.Context) (map[string]int64, error)
+}
+
+// RegisterOrderCounts exports order counts by status. They are read from the
+// database on every scrape, bounded by timeout.
+func (m *Metrics) RegisterOrderCounts(counter OrderCounter, timeout time.Duration) {
+	m.registry.MustRegister(&orderCollector{counter: counter, timeout: timeout})
+}
+
+var (
+	poolDescs = struct {
+		total, idle, acquired, max, acquires, emptyAcquires, canceledAcquires, acquireSeconds *prometheus.Desc
+	}{
+		total:            poolDesc("total_conns", "Connections currently open."),
+		idle:             poolDesc("idle_conns", "Idle connections."),
+		acquired:         poolDesc("acquired_conns", "Connections in use."),
+		max:              poolDesc("max_conns", "Maximum size of the pool."),
+		acquires:         poolDesc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquires:    poolDesc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquires: poolDesc("canceled_acquires_total", "Acquires canceled by their context."),
+		acquireSeconds:   poolDesc("acquire_duration_seconds_total", "Time spent waiting for connections."),
+	}
+	ordersDesc = prometheus.NewDesc(namespace+"_orders", "Orders by status.", []string{"status"}, nil)
+)
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(namespace+"_db_pool_"+name, help, nil, nil)
+}
+
+type poolCollector struct {
+	pool PoolStater
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolDescs.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.canceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolDescs.acquireSeconds, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
+
+type orderCollector struct {
+	counter OrderCounter
+	timeout time.Duration
+}
+
+func (c *orderCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ordersDesc
+}
+
+func (c *orderCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.counter.CountOrdersByStatus(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(ordersDesc, err)
+		return
+	}
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(ordersDesc, prometheus.GaugeValue, float64(n), status)
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOrderCounter struct {
+	counts map[string]int64
+	err    error
+}
+
+func (f fakeOrderCounter) CountOrdersByStatus(ctx context.Context) (map[string]int64, error) {
+	return f.counts, f.err
+}
+
+func TestMetrics_Observe(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest(http.MethodGet, "/api/user/orders", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/api/user/orders", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/user/orders", http.StatusConflict, time.Millisecond)
+	m.ObserveAccrualCall("429", time.Millisecond)
+	m.ObserveAccrualCall("200", time.Millisecond)
+	m.ObserveWithdrawal("")
+	m.ObserveWithdrawal("insufficient_funds")
+	m.ObserveWithdrawal("insufficient_funds")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/user/orders", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("POST", "/api/user/orders", "409")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.requestDuration))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.accrualCalls.WithLabelValues("429")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.withdrawals.WithLabelValues("success", "")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.withdrawals.WithLabelValues("failure", "insufficient_funds")))
+}
+
+func TestMetrics_Collectors(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/none")
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	m := New()
+	m.RegisterPool(pool)
+	m.RegisterOrderCounts(fakeOrderCounter{counts: map[string]int64{"NEW": 3, "PROCESSED": 7}}, time.Second)
+
+	expected := `
+# HELP gophermart_orders Orders by status.
+# TYPE gophermart_orders gauge
+gophermart_orders{status="NEW"} 3
+gophermart_orders{status="PROCESSED"} 7
+`
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected), "gophermart_orders"))
+
+	count, err := testutil.GatherAndCount(m.Registry(), "gophermart_db_pool_max_conns", "gophermart_db_pool_acquires_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "gophermart_db_pool_total_conns")
+}
+
+func TestMetrics_OrderCountFailure(t *testing.T) {
+	m := New()
+	m.RegisterOrderCounts(fakeOrderCounter{err: errors.New("connection refused")}, time.Second)
+
+	_, err := m.Registry().Gather()
+	assert.ErrorContains(t, err, "connection refused")
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeNotImplemented   = "not_implemented"
+	CodeUpstream         = "upstream_error"
+	CodeInternal         = "internal_error"
+)
+
+var errorTable = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{service.ErrInvalidOrder, http.StatusUnprocessableEntity, "invalid_order", "order number is invalid"},
+	{service.ErrInvalidSum, http.StatusUnprocessableEntity, CodeValidationFailed, "sum must be positive"},
+	{service.ErrInsufficientFunds, http.StatusPaymentRequired, "insufficient_funds", "not enough points on the balance"},
+	{service.ErrAlreadyUploadedOther, http.StatusConflict, "order_owned_by_another_user", "order was uploaded by another user"},
+	{service.ErrLoginTaken, http.StatusConflict, "login_taken", "login already taken"},
+	{service.ErrCredentialsRequired, http.StatusBadRequest, CodeValidationFailed, "login and password are required"},
+	{service.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "invalid login or password"},
+	{service.ErrHoldNotFound, http.StatusNotFound, "hold_not_found", "hold not found"},
+	{service.ErrHoldNotActive, http.StatusConflict, "hold_not_active", "hold was already settled or has expired"},
+	{service.ErrMemberNotFound, http.StatusNotFound, "member_not_found", "member not found"},
+	{service.ErrInvalidMember, http.StatusBadRequest, "invalid_member", "set either login or card to identify the member"},
+	{service.ErrInvalidMerchantToken, http.StatusUnauthorized, CodeUnauthorized, "invalid merchant token"},
+	{service.ErrMerchantNotFound, http.StatusNotFound, "merchant_not_found", "merchant not found"},
+	{service.ErrInvalidAPIKey, http.StatusUnauthorized, CodeUnauthorized, "invalid api key"},
+	{service.ErrInvalidScope, http.StatusUnprocessableEntity, "invalid_scope", "unknown or forbidden api key scope"},
+	{service.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found", "api key not found"},
+	{service.ErrUserNotFound, http.StatusNotFound, "user_not_found", "user not found"},
+	{service.ErrUserBlocked, http.StatusForbidden, "account_blocked", "account is blocked"},
+	{service.ErrInvalidRole, http.StatusUnprocessableEntity, "invalid_role", "unknown role"},
+	{service.ErrInvalidAdjustment, http.StatusUnprocessableEntity, "invalid_adjustment", "adjustment needs a non-zero amount and a reason"},
+	{service.ErrOrderNotFound, http.StatusNotFound, "order_not_found", "order not found"},
+	{service.ErrOrderFinal, http.StatusConflict, "order_final", "order is already processed"},
+	{service.ErrInvalidCampaign, http.StatusUnprocessableEntity, "invalid_campaign", "campaign needs a name, a known kind, a positive amount and a valid window"},
+	{service.ErrCampaignNotFound, http.StatusNotFound, "campaign_not_found", "campaign not found"},
+	{service.ErrInvalidAccrualRule, http.StatusUnprocessableEntity, "invalid_accrual_rule", "rule needs a name, a multiplier above 1 and below 1000 or a positive bonus, and a valid window"},
+	{service.ErrAccrualRuleNotFound, http.StatusNotFound, "accrual_rule_not_found", "accrual rule not found"},
+	{service.ErrInvalidReferralCode, http.StatusUnprocessableEntity, "invalid_referral_code", "referral code is unknown"},
+	{service.ErrSelfReferral, http.StatusUnprocessableEntity, "self_referral", "members cannot refer themselves"},
+	{service.ErrInvalidVoucherBatch, http.StatusUnprocessableEntity, "invalid_voucher_batch", "batch needs a name, a positive value, a count between 1 and 10000 and a future expiry"},
+	{service.ErrVoucherNotFound, http.StatusNotFound, "voucher_not_found", "voucher code is unknown"},
+	{service.ErrVoucherRedeemed, http.StatusConflict, "voucher_redeemed", "voucher was already redeemed"},
+	{service.ErrVoucherExpired, http.StatusGone, "voucher_expired", "voucher has expired"},
+	{service.ErrInvalidAuditFilter, http.StatusUnprocessableEntity, "invalid_audit_filter", "outcome must be success or failure and the time range must not be empty"},
+	{service.ErrInvalidWebhook, http.StatusUnprocessableEntity, "invalid_webhook", "webhook needs an absolute http or https url and known event types"},
+	{service.ErrTooManyWebhooks, http.StatusConflict, "too_many_webhooks", "webhook limit reached, delete an endpoint first"},
+	{service.ErrWebhookNotFound, http.StatusNotFound, "webhook_not_found", "webhook not found"},
+	{service.ErrDeliveryNotFound, http.StatusNotFound, "delivery_not_found", "webhook delivery not found"},
+	{service.ErrTooManyReq, http.StatusTooManyRequests, "too_many_requests", "accrual system is rate limiting requests"},
+	{service.ErrSerialization, http.StatusConflict, "concurrent_update", "request conflicted with a concurrent update, retry it"},
+}
+
+// kindTable answers domain errors that have no entry of their own in
+// errorTable. Messages are generic on purpose: a kinded error may wrap a
+// driver error whose text must not reach clients.
+var kindTable = map[service.Kind]struct {
+	status  int
+	code    string
+	message string
+}{
+	service.KindNotFound:     {http.StatusNotFound, CodeNotFound, "resource not found"},
+	service.KindConflict:     {http.StatusConflict, CodeConflict, "request conflicts with the current state"},
+	service.KindValidation:   {http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed"},
+	service.KindUnauthorized: {http.StatusUnauthorized, CodeUnauthorized, "authentication required"},
+	service.KindForbidden:    {http.StatusForbidden, CodeForbidden, "not allowed"},
+	service.KindUpstream:     {http.StatusBadGateway, CodeUpstream, "a dependency failed, try again later"},
+}
+
+func NewAPIError(status int, code, message string, details ...models.FieldError) *models.APIError {
+	return &models.APIError{Status: status, Code: code, Message: message, Details: details}
+}
+
+func ErrUnauthorized() *models.APIError {
+	return NewAPIError(http.StatusUnauthorized, CodeUnauthorized, "authentication required")
+}
+
+func ErrForbidden() *models.APIError {
+	return NewAPIError(http.StatusForbidden, CodeForbidden, "not allowed")
+}
+
+func ErrInvalidRequest(message string, details ...models.FieldError) *models.APIError {
+	return NewAPIError(http.StatusBadRequest, CodeInvalidRequest, message, details...)
+}
+
+// ToAPIError translates any error into the response envelope. Known sentinels
+// get their own code, other domain errors are answered by kind, and anything
+// else becomes a generic internal error so nothing leaks to clients.
+func ToAPIError(err error) *models.APIError {
+	var apiErr *models.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, e := range errorTable {
+		if errors.Is(err, e.err) {
+			return NewAPIError(e.status, e.code, e.message)
+		}
+	}
+
+	if k, ok := kindTable[service.KindOf(err)]; ok {
+		return NewAPIError(k.status, k.code, k.message)
+	}
+
+	return NewAPIError(http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+// AbortWithError records err on the context and writes its envelope.
+func AbortWithError(c *gin.Context, err error) {
+	apiErr := ToAPIError(err)
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(apiErr.Status, models.ErrorResponse{Error: apiErr})
+}
+
+// ErrorHandler is the central error middleware. It turns panics and errors
+// that handlers attached with c.Error without responding into envelopes.
+// Panics are logged with their stack; other errors reach the access log.
+func ErrorHandler(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(c.Request.Context(), "panic serving request",
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+					"panic", r,
+					"stack", string(debug.Stack()),
+				)
+				if !c.Writer.Written() {
+					AbortWithError(c, errors.New("panic"))
+				} else {
+					c.Abort()
+				}
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		apiErr := ToAPIError(c.Errors.Last().Err)
+		c.AbortWithStatusJSON(apiErr.Status, models.ErrorResponse{Error: apiErr})
+	}
+}
+
+func NotFound(c *gin.Context) {
+	AbortWithError(c, NewAPIError(http.StatusNotFound, CodeNotFound, "route not found"))
+}
+
+func MethodNotAllowed(c *gin.Context) {
+	AbortWithError(c, NewAPIError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed"))
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/Guldana11/gophermart/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits caller-supplied IDs to something safe to echo back
+// and to write into logs.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an ID, reusing the caller's X-Request-ID when
+// it looks sane. The ID is echoed in the response and stored in the request
+// context, where the logger picks it up.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLog writes one entry per request once it has been answered. Only the
+// path is logged: query strings, headers and bodies may carry credentials.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if id := c.GetString("merchantID"); id != "" {
+			attrs = append(attrs, slog.String("merchant_id", id))
+		}
+		if id := c.GetString("apiKeyID"); id != "" {
+			attrs = append(attrs, slog.String("api_key_id", id))
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+			if err := c.Errors.Last(); err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// setUser records the authenticated member for handlers and for the logs.
+func setUser(c *gin.Context, userID string) {
+	c.Set("userID", userID)
+	c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), userID))
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/gin-gonic/gin"
+)
+
+type MerchantAuthenticator interface {
+	AuthenticateMerchant(ctx context.Context, token string) (*models.Merchant, error)
+}
+
+// MerchantAuth accepts "Authorization: Bearer <token>" issued to a store and
+// stores the merchant ID in the context under "merchantID". Requests already
+// authenticated by a merchant API key are passed through.
+func MerchantAuth(auth MerchantAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("merchantID") != "" {
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			AbortWithError(c, ErrUnauthorized())
+			return
+		}
+
+		merchant, err := auth.AuthenticateMerchant(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			AbortWithError(c, err)
+			return
+		}
+
+		c.Set("merchantID", merchant.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/gin-gonic/gin"
+)
+
+type UserStatusChecker interface {
+	// UserStatus returns the member's current role and whether they are
+	// blocked.
+	UserStatus(ctx context.Context, userID string) (role string, blocked bool, err error)
+}
+
+// RequireRole lets through sessions whose role is one of roles. It must run
+// after RejectBlocked, which replaces the role the token was issued with by
+// the member's current one. API-key callers have no role and need the admin
+// scope instead.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get("apiKeyScopes"); ok {
+			scopes, _ := value.([]string)
+			if !slices.Contains(scopes, models.ScopeAdmin) {
+				AbortWithError(c, NewAPIError(http.StatusForbidden, CodeForbidden, "api key lacks scope "+models.ScopeAdmin))
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(roles, c.GetString("role")) {
+			AbortWithError(c, ErrForbidden())
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectBlocked stops members blocked after their token was issued and sets
+// the session's role to the member's current one, so a demotion takes effect
+// at once. Members that can no longer be looked up are treated as
+// unauthenticated.
+func RejectBlocked(checker UserStatusChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			c.Next()
+			return
+		}
+
+		role, blocked, err := checker.UserStatus(c.Request.Context(), userID)
+		if err != nil {
+			AbortWithError(c, ErrUnauthorized())
+			return
+		}
+		if blocked {
+			AbortWithError(c, service.ErrUserBlocked)
+			return
+		}
+		c.Set("role", role)
+		c.Next()
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS point_holds;
+
+ALTER TABLE user_points
+    DROP COLUMN IF EXISTS held_points;
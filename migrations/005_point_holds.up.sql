@@ -0,0 +1,18 @@
+ALTER TABLE user_points
+    ADD COLUMN IF NOT EXISTS held_points NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS point_holds (
+                                           order_number VARCHAR(255) PRIMARY KEY,
+    user_id UUID NOT NULL,
+    sum NUMERIC(12,2) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'AUTHORIZED',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_point_holds_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_point_holds_sum CHECK (sum > 0)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_point_holds_user ON point_holds (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_point_holds_expiry ON point_holds (expires_at) WHERE status = 'AUTHORIZED';
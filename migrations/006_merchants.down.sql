@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS merchant_purchases;
+DROP TABLE IF EXISTS merchants;
+
+DROP INDEX IF EXISTS idx_users_loyalty_card;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS loyalty_card;
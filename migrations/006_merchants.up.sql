@@ -0,0 +1,55 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS loyalty_card VARCHAR(16);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_loyalty_card ON users (loyalty_card);
+
+-- Issue Luhn-valid cards to members registered before cards existed.
+DO $$
+DECLARE
+    u RECORD;
+    card TEXT;
+    total INT;
+    digit INT;
+BEGIN
+    FOR u IN SELECT id FROM users WHERE loyalty_card IS NULL LOOP
+        LOOP
+            card := '7' || lpad(floor(random() * 1e14)::BIGINT::TEXT, 14, '0');
+            total := 0;
+            FOR i IN 1..15 LOOP
+                digit := substr(card, 16 - i, 1)::INT;
+                IF i % 2 = 1 THEN
+                    digit := digit * 2;
+                    IF digit > 9 THEN
+                        digit := digit - 9;
+                    END IF;
+                END IF;
+                total := total + digit;
+            END LOOP;
+            card := card || ((10 - total % 10) % 10)::TEXT;
+            EXIT WHEN NOT EXISTS (SELECT 1 FROM users WHERE loyalty_card = card);
+        END LOOP;
+        UPDATE users SET loyalty_card = card WHERE id = u.id;
+    END LOOP;
+END $$;
+
+CREATE TABLE IF NOT EXISTS merchants (
+                                         id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+    );
+
+CREATE TABLE IF NOT EXISTS merchant_purchases (
+                                                  order_number TEXT PRIMARY KEY,
+    merchant_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_merchant_purchases_merchant FOREIGN KEY (merchant_id)
+    REFERENCES merchants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_merchant_purchases_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_merchant_purchases_merchant ON merchant_purchases (merchant_id, created_at DESC);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS balance_adjustments;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS chk_users_role,
+    DROP COLUMN IF EXISTS blocked_at,
+    DROP COLUMN IF EXISTS role;
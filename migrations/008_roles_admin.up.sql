@@ -0,0 +1,21 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user',
+    ADD COLUMN IF NOT EXISTS blocked_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE users
+    ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'support', 'admin'));
+
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+                                                   id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    amount NUMERIC(12,2) NOT NULL,
+    reason TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_balance_adjustments_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_balance_adjustments_amount CHECK (amount <> 0),
+    CONSTRAINT chk_balance_adjustments_reason CHECK (length(trim(reason)) > 0)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_user ON balance_adjustments (user_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Credited grants stay on the members' balances; only their history goes.
+DROP TABLE IF EXISTS campaign_grants;
+DROP TABLE IF EXISTS campaigns;
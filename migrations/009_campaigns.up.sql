@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+                                         id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    amount NUMERIC(12,2) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE,
+    per_user_cap INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    disabled_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_campaigns_kind CHECK (kind IN ('signup', 'promo', 'first_order')),
+    CONSTRAINT chk_campaigns_amount CHECK (amount > 0),
+    CONSTRAINT chk_campaigns_window CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CONSTRAINT chk_campaigns_cap CHECK (per_user_cap > 0)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_kind ON campaigns (kind, starts_at);
+
+CREATE TABLE IF NOT EXISTS campaign_grants (
+                                               id BIGSERIAL PRIMARY KEY,
+    campaign_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    amount NUMERIC(12,2) NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_campaign_grants_campaign FOREIGN KEY (campaign_id)
+    REFERENCES campaigns(id) ON DELETE CASCADE,
+    CONSTRAINT fk_campaign_grants_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_campaign_grants_user ON campaign_grants (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_campaign_grants_campaign ON campaign_grants (campaign_id, user_id);
+
+-- The sign-up bonus used to be hardcoded in UserRepo.CreateUser; keep granting
+-- it to new members until an admin changes or disables the campaign.
+INSERT INTO campaigns (id, name, kind, amount, starts_at, per_user_cap)
+VALUES ('00000000-0000-0000-0000-000000000729', 'Welcome bonus', 'signup', 729.98, '2000-01-01', 1)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS accrual_rules;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS bonus;
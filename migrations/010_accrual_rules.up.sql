@@ -0,0 +1,24 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS bonus NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS accrual_rules (
+                                             id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    multiplier NUMERIC(6,3) NOT NULL DEFAULT 1,
+    bonus NUMERIC(12,2) NOT NULL DEFAULT 0,
+    min_accrual NUMERIC(12,2) NOT NULL DEFAULT 0,
+    max_bonus NUMERIC(12,2),
+    stackable BOOLEAN NOT NULL DEFAULT false,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    disabled_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT chk_accrual_rules_multiplier CHECK (multiplier >= 1),
+    CONSTRAINT chk_accrual_rules_bonus CHECK (bonus >= 0),
+    CONSTRAINT chk_accrual_rules_effect CHECK (multiplier > 1 OR bonus > 0),
+    CONSTRAINT chk_accrual_rules_min CHECK (min_accrual >= 0),
+    CONSTRAINT chk_accrual_rules_max CHECK (max_bonus IS NULL OR max_bonus > 0),
+    CONSTRAINT chk_accrual_rules_window CHECK (ends_at IS NULL OR ends_at > starts_at)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_accrual_rules_window ON accrual_rules (starts_at, ends_at);
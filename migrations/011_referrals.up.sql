@@ -0,0 +1,27 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS referral_code VARCHAR(16);
+
+UPDATE users
+SET referral_code = upper(substr(md5(random()::TEXT || id::TEXT), 1, 8))
+WHERE referral_code IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_referral_code ON users (referral_code);
+
+CREATE TABLE IF NOT EXISTS referrals (
+                                         referee_id UUID PRIMARY KEY,
+    referrer_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    order_number TEXT,
+    referrer_reward NUMERIC(12,2) NOT NULL DEFAULT 0,
+    referee_reward NUMERIC(12,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    rewarded_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_referrals_referee FOREIGN KEY (referee_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_referrals_referrer FOREIGN KEY (referrer_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_referrals_self CHECK (referee_id <> referrer_id),
+    CONSTRAINT chk_referrals_status CHECK (status IN ('PENDING', 'REWARDED'))
+    );
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals (referrer_id, created_at DESC);
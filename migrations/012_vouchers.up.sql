@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS voucher_batches (
+                                               id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    value NUMERIC(12,2) NOT NULL,
+    count INT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_voucher_batches_value CHECK (value > 0),
+    CONSTRAINT chk_voucher_batches_count CHECK (count > 0)
+    );
+
+-- Only a hash of each code is stored, like API keys: a leaked table does not
+-- hand out redeemable codes.
+CREATE TABLE IF NOT EXISTS vouchers (
+                                        code_hash TEXT PRIMARY KEY,
+    batch_id UUID NOT NULL,
+    value NUMERIC(12,2) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    redeemed_by UUID,
+    redeemed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_vouchers_batch FOREIGN KEY (batch_id)
+    REFERENCES voucher_batches(id) ON DELETE CASCADE,
+    CONSTRAINT fk_vouchers_user FOREIGN KEY (redeemed_by)
+    REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_vouchers_redeemed CHECK (redeemed_by IS NULL OR redeemed_at IS NOT NULL)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_vouchers_batch ON vouchers (batch_id);
+CREATE INDEX IF NOT EXISTS idx_vouchers_redeemed_by ON vouchers (redeemed_by, redeemed_at DESC);
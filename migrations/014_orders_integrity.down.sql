@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_orders_user_uploaded;
+
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS chk_orders_amounts,
+    DROP CONSTRAINT IF EXISTS chk_orders_status,
+    DROP CONSTRAINT IF EXISTS fk_orders_user,
+    ALTER COLUMN accrual DROP NOT NULL,
+    ALTER COLUMN accrual TYPE NUMERIC(10,2),
+    ALTER COLUMN user_id TYPE TEXT USING user_id::TEXT;
+
+INSERT INTO orders (id, number, user_id, status, accrual, uploaded_at, bonus)
+SELECT id, number, user_id, status, accrual, uploaded_at, bonus FROM orders_orphaned;
+
+DROP TABLE IF EXISTS orders_orphaned;
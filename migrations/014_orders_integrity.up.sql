@@ -0,0 +1,35 @@
+-- Orders whose user_id does not name an existing member cannot satisfy the
+-- foreign key below. They are moved aside instead of deleted, so they can be
+-- reviewed and moved back once their owner is known.
+CREATE TABLE IF NOT EXISTS orders_orphaned (LIKE orders INCLUDING DEFAULTS);
+ALTER TABLE orders_orphaned
+    ADD COLUMN IF NOT EXISTS moved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+WITH orphans AS (
+    DELETE FROM orders o
+    WHERE NOT EXISTS (
+        SELECT 1 FROM users u
+        WHERE u.id = CASE
+            WHEN o.user_id ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+            THEN o.user_id::UUID
+        END
+    )
+    RETURNING o.*
+)
+INSERT INTO orders_orphaned SELECT *, NOW() FROM orphans;
+
+-- The accrual system's REGISTERED used to be stored as is; members see it as
+-- PROCESSING.
+UPDATE orders SET status = 'PROCESSING' WHERE status = 'REGISTERED';
+UPDATE orders SET accrual = 0 WHERE accrual IS NULL;
+
+ALTER TABLE orders
+    ALTER COLUMN user_id TYPE UUID USING user_id::UUID,
+    ALTER COLUMN accrual TYPE NUMERIC(12,2),
+    ALTER COLUMN accrual SET NOT NULL,
+    ADD CONSTRAINT fk_orders_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_orders_status CHECK (status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED')),
+    ADD CONSTRAINT chk_orders_amounts CHECK (accrual >= 0 AND bonus >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_uploaded ON orders (user_id, uploaded_at DESC);
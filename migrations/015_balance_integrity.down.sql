@@ -0,0 +1,13 @@
+ALTER TABLE point_holds
+    DROP CONSTRAINT IF EXISTS chk_point_holds_status;
+
+DROP INDEX IF EXISTS idx_withdrawals_user_processed;
+
+ALTER TABLE withdrawals
+    DROP CONSTRAINT IF EXISTS chk_withdrawals_sum,
+    DROP CONSTRAINT IF EXISTS fk_withdrawals_user;
+
+INSERT INTO withdrawals (order_number, user_id, sum, processed_at)
+SELECT order_number, user_id, sum, processed_at FROM withdrawals_orphaned;
+
+DROP TABLE IF EXISTS withdrawals_orphaned;
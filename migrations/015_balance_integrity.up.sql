@@ -0,0 +1,22 @@
+-- Same as for orders: withdrawals of members that no longer exist are kept
+-- aside rather than lost.
+CREATE TABLE IF NOT EXISTS withdrawals_orphaned (LIKE withdrawals INCLUDING DEFAULTS);
+ALTER TABLE withdrawals_orphaned
+    ADD COLUMN IF NOT EXISTS moved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+WITH orphans AS (
+    DELETE FROM withdrawals w
+    WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = w.user_id)
+    RETURNING w.*
+)
+INSERT INTO withdrawals_orphaned SELECT *, NOW() FROM orphans;
+
+ALTER TABLE withdrawals
+    ADD CONSTRAINT fk_withdrawals_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_withdrawals_sum CHECK (sum > 0);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user_processed ON withdrawals (user_id, processed_at DESC);
+
+ALTER TABLE point_holds
+    ADD CONSTRAINT chk_point_holds_status CHECK (status IN ('AUTHORIZED', 'CAPTURED', 'VOIDED', 'EXPIRED'));
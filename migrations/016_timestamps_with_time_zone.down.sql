@@ -0,0 +1,5 @@
+ALTER TABLE orders
+    ALTER COLUMN uploaded_at TYPE TIMESTAMP;
+
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMP;
@@ -0,0 +1,7 @@
+-- Existing values are read in the session time zone, the one NOW() used when
+-- it filled them in.
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE orders
+    ALTER COLUMN uploaded_at TYPE TIMESTAMP WITH TIME ZONE;
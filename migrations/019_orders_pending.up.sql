@@ -0,0 +1,2 @@
+-- The accrual worker pages through unfinished orders by number.
+CREATE INDEX IF NOT EXISTS idx_orders_pending ON orders (number) WHERE status IN ('NEW', 'PROCESSING');
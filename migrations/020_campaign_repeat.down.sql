@@ -0,0 +1,2 @@
+ALTER TABLE campaigns DROP CONSTRAINT IF EXISTS chk_campaigns_repeat;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS repeat_every_seconds;
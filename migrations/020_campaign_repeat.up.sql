@@ -0,0 +1,7 @@
+-- A campaign pays a member once. Only promos that set repeat_every_seconds
+-- pay again, at most once per interval and up to per_user_cap grants.
+ALTER TABLE campaigns
+    ADD COLUMN IF NOT EXISTS repeat_every_seconds INT NOT NULL DEFAULT 0;
+
+ALTER TABLE campaigns
+    ADD CONSTRAINT chk_campaigns_repeat CHECK (repeat_every_seconds >= 0);
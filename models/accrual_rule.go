@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// AccrualRule adds points on top of the accrual system's award for orders
+// uploaded inside its window. The bonus of a rule is
+// base*(Multiplier-1) + Bonus, limited to MaxBonus when set. Stackable rules
+// add up; of the other rules only the most generous one applies.
+type AccrualRule struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Multiplier float64    `json:"multiplier"`
+	Bonus      float64    `json:"bonus"`
+	MinAccrual float64    `json:"min_accrual"`
+	MaxBonus   *float64   `json:"max_bonus,omitempty"`
+	Stackable  bool       `json:"stackable"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+type CreateAccrualRuleRequest struct {
+	Name       string     `json:"name"`
+	Multiplier float64    `json:"multiplier,omitempty"`
+	Bonus      float64    `json:"bonus,omitempty"`
+	MinAccrual float64    `json:"min_accrual,omitempty"`
+	MaxBonus   *float64   `json:"max_bonus,omitempty"`
+	Stackable  bool       `json:"stackable,omitempty"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+}
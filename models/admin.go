@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type UserAccount struct {
+	User    User            `json:"user"`
+	Balance BalanceResponse `json:"balance"`
+}
+
+type BalanceAdjustment struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"user_id"`
+	Amount    float64   `json:"amount"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BalanceAdjustmentRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+const (
+	ScopeOrdersRead   = "orders:read"
+	ScopeOrdersWrite  = "orders:write"
+	ScopeBalanceRead  = "balance:read"
+	ScopeBalanceWrite = "balance:write"
+	ScopeWebhooks     = "webhooks"
+	ScopeAdmin        = "admin"
+)
+
+// UserScopes are the scopes a member may grant to keys acting on their behalf.
+var UserScopes = []string{ScopeOrdersRead, ScopeOrdersWrite, ScopeBalanceRead, ScopeBalanceWrite, ScopeWebhooks}
+
+// MerchantScopes are the scopes a store integration's key may hold.
+var MerchantScopes = []string{ScopeOrdersWrite, ScopeWebhooks}
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	UserID     string     `json:"-"`
+	MerchantID string     `json:"merchant_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateServiceKeyRequest is how admins issue keys: for the store MerchantID
+// when it is set, otherwise a service key.
+type CreateServiceKeyRequest struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	MerchantID string   `json:"merchant_id,omitempty"`
+}
+
+// CreatedAPIKey is returned once, when the key is issued; Key is never stored.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
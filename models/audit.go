@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+const (
+	AuditActionRegister = "user.register"
+	AuditActionLogin    = "auth.login"
+	AuditActionWithdraw = "balance.withdraw"
+	// Admin actions are recorded as "admin.<METHOD> <route>".
+	AuditActionAdminPrefix = "admin."
+	// gophermartctl commands are recorded as "cli.<command>".
+	AuditActionCLIPrefix = "cli."
+)
+
+type AuditEvent struct {
+	ID         int64          `json:"id"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Action     string         `json:"action"`
+	Outcome    string         `json:"outcome"`
+	Actor      string         `json:"actor"`
+	Subject    string         `json:"subject,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"user_agent"`
+	RequestID  string         `json:"request_id"`
+}
+
+// AuditFilter narrows an audit query. Empty fields match everything; BeforeID
+// pages backwards from the last event of the previous page.
+type AuditFilter struct {
+	Action   string
+	Actor    string
+	Subject  string
+	Outcome  string
+	From     *time.Time
+	To       *time.Time
+	BeforeID int64
+	Limit    int
+}
@@ -5,6 +5,7 @@ import "time"
 type BalanceResponse struct {
 	Current   float64 `json:"current"`
 	Withdrawn float64 `json:"withdrawn"`
+	Held      float64 `json:"held,omitempty"`
 }
 
 type WithdrawRequest struct {
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	CampaignKindSignup     = "signup"
+	CampaignKindPromo      = "promo"
+	CampaignKindFirstOrder = "first_order"
+)
+
+// Campaign is an admin-defined points grant. Sign-up campaigns are granted at
+// registration, promo campaigns when a member logs in during the window, and
+// first-order campaigns when a member's first order is processed. Each pays a
+// member once, unless a promo sets RepeatEverySeconds: it then pays again on
+// a login at least that long after the last grant, up to PerUserCap grants.
+type Campaign struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Kind       string     `json:"kind"`
+	Amount     float64    `json:"amount"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	PerUserCap int        `json:"per_user_cap"`
+	// RepeatEverySeconds is zero for campaigns that pay once.
+	RepeatEverySeconds int        `json:"repeat_every_seconds,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	DisabledAt         *time.Time `json:"disabled_at,omitempty"`
+}
+
+type CampaignGrant struct {
+	ID         int64     `json:"id"`
+	CampaignID string    `json:"campaign_id"`
+	UserID     string    `json:"-"`
+	Amount     float64   `json:"amount"`
+	Reference  string    `json:"reference,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateCampaignRequest struct {
+	Name               string     `json:"name"`
+	Kind               string     `json:"kind"`
+	Amount             float64    `json:"amount"`
+	StartsAt           *time.Time `json:"starts_at,omitempty"`
+	EndsAt             *time.Time `json:"ends_at,omitempty"`
+	PerUserCap         int        `json:"per_user_cap,omitempty"`
+	RepeatEverySeconds int        `json:"repeat_every_seconds,omitempty"`
+}
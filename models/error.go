@@ -0,0 +1,24 @@
+package models
+
+// FieldError points at a single invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is the body of every error response. Code is stable and meant for
+// programs; Message is for people and may change.
+type APIError struct {
+	Status  int          `json:"-"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain events other systems can subscribe to.
+const (
+	EventOrderProcessed  = "order.processed"
+	EventPointsCredited  = "points.credited"
+	EventPointsWithdrawn = "points.withdrawn"
+)
+
+// EventTypes lists every event type, in the order they are documented.
+var EventTypes = []string{EventOrderProcessed, EventPointsCredited, EventPointsWithdrawn}
+
+// Where the points of a points.credited event came from.
+const (
+	CreditSourceOrder      = "order"
+	CreditSourceCampaign   = "campaign"
+	CreditSourceReferral   = "referral"
+	CreditSourceVoucher    = "voucher"
+	CreditSourceAdjustment = "adjustment"
+)
+
+// Event is a domain event recorded in the outbox. Seq orders the events of a
+// member; consumers deduplicate on ID, since delivery is at least once.
+type Event struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	UserID     string          `json:"user_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+	Attempts   int             `json:"-"`
+}
+
+type OrderProcessedData struct {
+	Order   string  `json:"order"`
+	Accrual float64 `json:"accrual"`
+	Bonus   float64 `json:"bonus"`
+}
+
+// PointsCreditedData is negative when an earlier credit is taken back, e.g.
+// when a processed order is re-scored lower or an admin deducts points.
+type PointsCreditedData struct {
+	Amount    float64 `json:"amount"`
+	Source    string  `json:"source"`
+	Reference string  `json:"reference,omitempty"`
+}
+
+type PointsWithdrawnData struct {
+	Order string  `json:"order"`
+	Sum   float64 `json:"sum"`
+}
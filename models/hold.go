@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	HoldStatusAuthorized = "AUTHORIZED"
+	HoldStatusCaptured   = "CAPTURED"
+	HoldStatusVoided     = "VOIDED"
+	HoldStatusExpired    = "EXPIRED"
+)
+
+type PointHold struct {
+	OrderNumber string    `json:"order"`
+	UserID      string    `json:"-"`
+	Sum         float64   `json:"sum"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type HoldRequest struct {
+	Order      string  `json:"order"`
+	Sum        float64 `json:"sum"`
+	TTLSeconds int     `json:"ttl_seconds,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Merchant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MerchantPurchaseRequest identifies the member either by login or by
+// loyalty card number; exactly one of them must be set.
+type MerchantPurchaseRequest struct {
+	Order  string  `json:"order"`
+	Login  string  `json:"login,omitempty"`
+	Card   string  `json:"card,omitempty"`
+	Amount float64 `json:"amount,omitempty"`
+}
+
+type MerchantPurchase struct {
+	OrderNumber string    `json:"order"`
+	MerchantID  string    `json:"merchant_id"`
+	UserID      string    `json:"user_id"`
+	Amount      float64   `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
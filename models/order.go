@@ -2,12 +2,20 @@ package models
 
 import "time"
 
+const (
+	OrderStatusNew        = "NEW"
+	OrderStatusProcessing = "PROCESSING"
+	OrderStatusInvalid    = "INVALID"
+	OrderStatusProcessed  = "PROCESSED"
+)
+
 type Order struct {
 	ID         int       `json:"id"`
 	Number     string    `json:"number"`
 	UserID     string    `json:"userId"`
 	Status     string    `json:"status"`
 	Accrual    float64   `json:"accrual,omitempty"`
+	Bonus      float64   `json:"bonus,omitempty"`
 	UploadedAt time.Time `json:"uploadedAt"`
 }
 
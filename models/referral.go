@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	ReferralStatusPending  = "PENDING"
+	ReferralStatusRewarded = "REWARDED"
+)
+
+// Referral links a member to the member whose code they signed up with. Both
+// are rewarded once the referee's first order is processed; ReferrerReward is
+// zero when the referrer had already reached the reward cap.
+type Referral struct {
+	RefereeID      string     `json:"-"`
+	RefereeLogin   string     `json:"login"`
+	ReferrerID     string     `json:"-"`
+	Status         string     `json:"status"`
+	OrderNumber    string     `json:"order,omitempty"`
+	ReferrerReward float64    `json:"reward,omitempty"`
+	RefereeReward  float64    `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RewardedAt     *time.Time `json:"rewarded_at,omitempty"`
+}
@@ -2,14 +2,25 @@ package models
 
 import "time"
 
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
 type User struct {
-	ID           string    `json:"id"`
-	Login        string    `json:"login"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           string     `json:"id"`
+	Login        string     `json:"login"`
+	PasswordHash string     `json:"-"`
+	LoyaltyCard  string     `json:"loyalty_card,omitempty"`
+	ReferralCode string     `json:"referral_code,omitempty"`
+	Role         string     `json:"role"`
+	BlockedAt    *time.Time `json:"blocked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
 }
 
 type RegisterRequest struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login        string `json:"login"`
+	Password     string `json:"password"`
+	ReferralCode string `json:"referral_code,omitempty"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type VoucherBatch struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Value     float64    `json:"value"`
+	Count     int        `json:"count"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreatedVoucherBatch carries the plain codes. They are returned only when
+// the batch is generated; afterwards only their hashes exist.
+type CreatedVoucherBatch struct {
+	VoucherBatch
+	Codes []string `json:"codes"`
+}
+
+type CreateVoucherBatchRequest struct {
+	Name      string     `json:"name"`
+	Value     float64    `json:"value"`
+	Count     int        `json:"count"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type RedeemVoucherRequest struct {
+	Code string `json:"code"`
+}
+
+type VoucherRedemption struct {
+	Value      float64   `json:"value"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+type VoucherBatchReport struct {
+	VoucherBatch
+	IssuedValue   float64 `json:"issued_value"`
+	Redeemed      int     `json:"redeemed"`
+	RedeemedValue float64 `json:"redeemed_value"`
+	Expired       int     `json:"expired"`
+	ExpiredValue  float64 `json:"expired_value"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryStatusPending   = "PENDING"
+	DeliveryStatusDelivered = "DELIVERED"
+	DeliveryStatusFailed    = "FAILED"
+)
+
+// WebhookOwner is the member or the merchant an endpoint belongs to; exactly
+// one of the IDs is set.
+type WebhookOwner struct {
+	UserID     string
+	MerchantID string
+}
+
+// WebhookEndpoint receives the events listed in Events, or every event when
+// Events is empty. A member's endpoint gets the member's events; a merchant's
+// gets order.processed for the orders the merchant registered.
+type WebhookEndpoint struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	UserID              string     `json:"-"`
+	MerchantID          string     `json:"-"`
+	CreatedAt           time.Time  `json:"created_at"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// CreatedWebhookEndpoint is returned once, when the endpoint is registered;
+// Secret verifies the signature of every delivery.
+type CreatedWebhookEndpoint struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to one endpoint.
+// Payload is the JSON event, kept so a replay sends the same body.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"-"`
+	URL            string          `json:"-"`
+	Secret         string          `json:"-"`
+}
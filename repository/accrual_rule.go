@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type AccrualRuleRepository interface {
+	CreateRule(ctx context.Context, rule models.AccrualRule) error
+	ListRules(ctx context.Context) ([]models.AccrualRule, error)
+	DisableRule(ctx context.Context, id string) error
+	// RulesAt returns the enabled rules whose window contains at.
+	RulesAt(ctx context.Context, at time.Time) ([]models.AccrualRule, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type AdminRepository interface {
+	SearchUsers(ctx context.Context, login string, limit int) ([]models.User, error)
+	GetUserByID(ctx context.Context, userID string) (*models.User, error)
+	SetUserBlocked(ctx context.Context, userID string, blocked bool) error
+	SetUserRole(ctx context.Context, userID, role string) error
+	AdjustBalance(ctx context.Context, adj models.BalanceAdjustment) (*models.BalanceAdjustment, error)
+	GetAdjustments(ctx context.Context, userID string) ([]models.BalanceAdjustment, error)
+}
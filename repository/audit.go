@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type AuditRepository interface {
+	AppendAuditEvent(ctx context.Context, event models.AuditEvent) error
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+	// PurgeAuditEvents deletes events that occurred before cutoff.
+	PurgeAuditEvents(ctx context.Context, cutoff time.Time) (int64, error)
+}
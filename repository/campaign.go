@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type CampaignRepository interface {
+	CreateCampaign(ctx context.Context, campaign models.Campaign) error
+	ListCampaigns(ctx context.Context) ([]models.Campaign, error)
+	DisableCampaign(ctx context.Context, id string) error
+	ActiveCampaigns(ctx context.Context, kind string, at time.Time) ([]models.Campaign, error)
+	// GrantCampaign credits the grant unless the member already holds one
+	// from the campaign. A campaign that repeats credits it again once
+	// RepeatEverySeconds have passed since the member's last grant, until
+	// they hold PerUserCap grants. It reports whether points were credited.
+	GrantCampaign(ctx context.Context, grant models.CampaignGrant, campaign models.Campaign) (bool, error)
+	GetUserGrants(ctx context.Context, userID string) ([]models.CampaignGrant, error)
+}
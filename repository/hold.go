@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type HoldRepository interface {
+	AuthorizeHold(ctx context.Context, hold models.PointHold) error
+	CaptureHold(ctx context.Context, userID, order string) (*models.PointHold, error)
+	VoidHold(ctx context.Context, userID, order string) (*models.PointHold, error)
+	ExpireHolds(ctx context.Context, now time.Time) (int, error)
+	GetHeldPoints(ctx context.Context, userID string) (float64, error)
+	GetUserHolds(ctx context.Context, userID string) ([]models.PointHold, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type MerchantRepository interface {
+	CreateMerchant(ctx context.Context, merchant models.Merchant, tokenHash string) error
+	GetMerchantByTokenHash(ctx context.Context, tokenHash string) (*models.Merchant, error)
+	FindMember(ctx context.Context, login, card string) (*models.User, error)
+	SavePurchase(ctx context.Context, purchase models.MerchantPurchase) error
+}
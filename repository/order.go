@@ -10,4 +10,12 @@ type OrderRepository interface {
 	CheckOrderExists(ctx context.Context, orderNumber string) (string, bool, error)
 	CreateOrder(ctx context.Context, order models.Order) error
 	GetOrdersByUser(ctx context.Context, userID string) ([]models.Order, error)
+	GetOrder(ctx context.Context, orderNumber string) (*models.Order, error)
+	// UpdateOrderAccrual stores the order's status, base accrual and promotional
+	// bonus; PROCESSED orders are credited with accrual+bonus.
+	UpdateOrderAccrual(ctx context.Context, orderNumber, status string, accrual, bonus float64) error
+	// GetPendingOrders returns up to limit NEW and PROCESSING orders numbered
+	// after the given one, in number order, for the accrual worker to page
+	// through.
+	GetPendingOrders(ctx context.Context, after string, limit int) ([]models.Order, error)
 }
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type OutboxRepository interface {
+	// ClaimEvents returns up to limit events due at now, oldest first, and
+	// never an event whose member has an earlier event waiting for a retry.
+	// The claimed events are not due again until leaseUntil, so they can be
+	// published after the claim commits. It must run in a unit of work; ok is
+	// false when another relay holds the outbox for the rest of the
+	// transaction.
+	ClaimEvents(ctx context.Context, now, leaseUntil time.Time, limit int) (events []models.Event, ok bool, err error)
+	MarkPublished(ctx context.Context, seq int64, at time.Time) error
+	MarkFailed(ctx context.Context, seq int64, reason string, retryAt time.Time) error
+	// ReleaseEvents makes claimed events due at at again without counting an
+	// attempt.
+	ReleaseEvents(ctx context.Context, seqs []int64, at time.Time) error
+	// PurgePublished deletes events published before cutoff.
+	PurgePublished(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type ReferralRepository interface {
+	GetUserByReferralCode(ctx context.Context, code string) (*models.User, error)
+	CreateReferral(ctx context.Context, referral models.Referral) error
+	// RewardReferral credits both sides of the member's pending referral and
+	// marks it rewarded. The referrer is only credited while they have fewer
+	// than maxRewards rewarded referrals. It returns nil when nothing was
+	// pending.
+	RewardReferral(ctx context.Context, refereeID, orderNumber string, referrerReward, refereeReward float64, maxRewards int) (*models.Referral, error)
+	ListReferrals(ctx context.Context, referrerID string) ([]models.Referral, error)
+}
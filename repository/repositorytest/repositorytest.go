@@ -0,0 +1,350 @@
+// Package repositorytest is the conformance suite every storage backend runs:
+// it pins down the behaviour the services rely on, such as which error a
+// duplicate login or an overdraft produces and in which order lists come back.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Backend is the set of repositories under test. They must share storage: an
+// order credited through Orders shows up in the balance Users reports.
+type Backend struct {
+	Users  repository.UserRepository
+	Orders repository.OrderRepository
+}
+
+// Run runs the suite. newBackend is called once per subtest; backends backed
+// by a shared database may return the same repositories every time, since the
+// suite only touches rows it created under unique logins and order numbers.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newBackend(t)) })
+	t.Run("Orders", func(t *testing.T) { testOrders(t, newBackend(t)) })
+	t.Run("Accrual", func(t *testing.T) { testAccrual(t, newBackend(t)) })
+	t.Run("PendingOrders", func(t *testing.T) { testPendingOrders(t, newBackend(t)) })
+	t.Run("Withdraw", func(t *testing.T) { testWithdraw(t, newBackend(t)) })
+	t.Run("Cents", func(t *testing.T) { testCents(t, newBackend(t)) })
+}
+
+var seq atomic.Int64
+
+// unique returns a value no other run of the suite uses, so it can be used as
+// a login against a database that outlives the test.
+func unique(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), seq.Add(1))
+}
+
+// uniqueNumber is unique like unique, in digits only as order numbers are.
+func uniqueNumber() string {
+	return fmt.Sprintf("%d%d", time.Now().UnixNano(), seq.Add(1))
+}
+
+func createUser(t *testing.T, b Backend) *models.User {
+	t.Helper()
+	user, err := b.Users.CreateUser(context.Background(), unique("member"), "secret")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	return user
+}
+
+func createOrder(t *testing.T, b Backend, userID string) string {
+	t.Helper()
+	number := uniqueNumber()
+	if err := b.Orders.CreateOrder(context.Background(), models.Order{UserID: userID, Number: number}); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	return number
+}
+
+func expectErr(t *testing.T, call string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s error = %v, expected %v", call, err, want)
+	}
+}
+
+func expectBalance(t *testing.T, b Backend, userID string, current, withdrawn float64) {
+	t.Helper()
+	gotCurrent, gotWithdrawn, err := b.Users.GetUserPoints(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetUserPoints() error = %v", err)
+	}
+	if gotCurrent != current || gotWithdrawn != withdrawn {
+		t.Errorf("balance = %v/%v, expected %v/%v", gotCurrent, gotWithdrawn, current, withdrawn)
+	}
+}
+
+func testUsers(t *testing.T, b Backend) {
+	ctx := context.Background()
+	login := unique("member")
+
+	user, err := b.Users.CreateUser(ctx, login, "secret")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.ID == "" || user.Login != login || user.Role != models.RoleUser || user.LoyaltyCard == "" || user.ReferralCode == "" {
+		t.Errorf("CreateUser() = %+v, expected an ID, the login, the member role, a card and a referral code", user)
+	}
+	if user.PasswordHash == "secret" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("secret")) != nil {
+		t.Error("CreateUser() did not store a bcrypt hash of the password")
+	}
+
+	_, err = b.Users.CreateUser(ctx, login, "other")
+	expectErr(t, "CreateUser(duplicate login)", err, service.ErrLoginTaken)
+
+	got, err := b.Users.GetUserByLogin(ctx, login)
+	if err != nil {
+		t.Fatalf("GetUserByLogin() error = %v", err)
+	}
+	if got.ID != user.ID || got.PasswordHash != user.PasswordHash || got.BlockedAt != nil {
+		t.Errorf("GetUserByLogin() = %+v, expected the created user %+v", got, user)
+	}
+
+	_, err = b.Users.GetUserByLogin(ctx, unique("nobody"))
+	expectErr(t, "GetUserByLogin(unknown)", err, service.ErrUserNotFound)
+
+	expectBalance(t, b, user.ID, 0, 0)
+}
+
+func testOrders(t *testing.T, b Backend) {
+	ctx := context.Background()
+	owner := createUser(t, b)
+	other := createUser(t, b)
+
+	first := createOrder(t, b, owner.ID)
+	time.Sleep(5 * time.Millisecond)
+	second := createOrder(t, b, owner.ID)
+
+	userID, exists, err := b.Orders.CheckOrderExists(ctx, first)
+	if err != nil || !exists || userID != owner.ID {
+		t.Errorf("CheckOrderExists() = %q, %t, %v, expected the owner", userID, exists, err)
+	}
+	if _, exists, err := b.Orders.CheckOrderExists(ctx, uniqueNumber()); err != nil || exists {
+		t.Errorf("CheckOrderExists(unknown) = %t, %v, expected false", exists, err)
+	}
+
+	for _, userID := range []string{owner.ID, other.ID} {
+		err := b.Orders.CreateOrder(ctx, models.Order{UserID: userID, Number: first})
+		expectErr(t, "CreateOrder(duplicate number)", err, service.ErrAlreadyUploadedOther)
+	}
+
+	err = b.Orders.CreateOrder(ctx, models.Order{UserID: uuid.New().String(), Number: uniqueNumber()})
+	expectErr(t, "CreateOrder(unknown user)", err, service.ErrInvalidReference)
+
+	err = b.Orders.CreateOrder(ctx, models.Order{UserID: owner.ID, Number: "12a4"})
+	if service.KindOf(err) != service.KindValidation {
+		t.Errorf("CreateOrder(non-digit number) error = %v, expected a validation error", err)
+	}
+
+	order, err := b.Orders.GetOrder(ctx, first)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if order.Number != first || order.UserID != owner.ID || order.Status != models.OrderStatusNew || order.Accrual != 0 || order.UploadedAt.IsZero() {
+		t.Errorf("GetOrder() = %+v, expected a NEW order of the owner", order)
+	}
+
+	_, err = b.Orders.GetOrder(ctx, uniqueNumber())
+	expectErr(t, "GetOrder(unknown)", err, service.ErrOrderNotFound)
+
+	orders, err := b.Orders.GetOrdersByUser(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("GetOrdersByUser() error = %v", err)
+	}
+	if len(orders) != 2 || orders[0].Number != second || orders[1].Number != first {
+		t.Errorf("GetOrdersByUser() = %+v, expected %s then %s", orders, second, first)
+	}
+
+	orders, err = b.Orders.GetOrdersByUser(ctx, other.ID)
+	if err != nil || len(orders) != 0 {
+		t.Errorf("GetOrdersByUser(no orders) = %+v, %v, expected none", orders, err)
+	}
+}
+
+func testAccrual(t *testing.T, b Backend) {
+	ctx := context.Background()
+	user := createUser(t, b)
+	number := createOrder(t, b, user.ID)
+
+	steps := []struct {
+		status         string
+		accrual, bonus float64
+		balance        float64
+	}{
+		{models.OrderStatusProcessing, 0, 0, 0},
+		{models.OrderStatusProcessed, 500, 25, 525},
+		{models.OrderStatusProcessed, 400, 0, 400},
+		{models.OrderStatusInvalid, 0, 0, 0},
+	}
+	for _, step := range steps {
+		if err := b.Orders.UpdateOrderAccrual(ctx, number, step.status, step.accrual, step.bonus); err != nil {
+			t.Fatalf("UpdateOrderAccrual(%s, %v, %v) error = %v", step.status, step.accrual, step.bonus, err)
+		}
+		expectBalance(t, b, user.ID, step.balance, 0)
+	}
+
+	order, err := b.Orders.GetOrder(ctx, number)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if order.Status != models.OrderStatusInvalid {
+		t.Errorf("GetOrder().Status = %s, expected %s", order.Status, models.OrderStatusInvalid)
+	}
+
+	err = b.Orders.UpdateOrderAccrual(ctx, uniqueNumber(), models.OrderStatusProcessed, 1, 0)
+	expectErr(t, "UpdateOrderAccrual(unknown)", err, service.ErrOrderNotFound)
+
+	for _, bad := range []struct {
+		status         string
+		accrual, bonus float64
+	}{
+		{"REGISTERED", 0, 0},
+		{models.OrderStatusProcessed, -1, 0},
+	} {
+		err := b.Orders.UpdateOrderAccrual(ctx, number, bad.status, bad.accrual, bad.bonus)
+		if service.KindOf(err) != service.KindValidation {
+			t.Errorf("UpdateOrderAccrual(%s, %v) error = %v, expected a validation error", bad.status, bad.accrual, err)
+		}
+	}
+}
+
+func testPendingOrders(t *testing.T, b Backend) {
+	ctx := context.Background()
+	user := createUser(t, b)
+	lo, hi := createOrder(t, b, user.ID), createOrder(t, b, user.ID)
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+
+	pending := func(after string) []string {
+		t.Helper()
+		orders, err := b.Orders.GetPendingOrders(ctx, after, 1000)
+		if err != nil {
+			t.Fatalf("GetPendingOrders() error = %v", err)
+		}
+		var numbers []string
+		for _, o := range orders {
+			if o.Number <= after {
+				t.Errorf("GetPendingOrders(%s) returned %s", after, o.Number)
+			}
+			if o.Status != models.OrderStatusNew && o.Status != models.OrderStatusProcessing {
+				t.Errorf("GetPendingOrders() returned %s order %s", o.Status, o.Number)
+			}
+			numbers = append(numbers, o.Number)
+		}
+		if !slices.IsSorted(numbers) {
+			t.Errorf("GetPendingOrders() = %v, expected number order", numbers)
+		}
+		return numbers
+	}
+
+	// Other runs may have left pending orders behind, so start just below lo.
+	after := lo[:len(lo)-1]
+	if got := pending(after); !slices.Contains(got, lo) || !slices.Contains(got, hi) {
+		t.Errorf("GetPendingOrders() = %v, expected %s and %s", got, lo, hi)
+	}
+	if got := pending(lo); slices.Contains(got, lo) || !slices.Contains(got, hi) {
+		t.Errorf("GetPendingOrders(%s) = %v, expected %s only", lo, got, hi)
+	}
+
+	if err := b.Orders.UpdateOrderAccrual(ctx, lo, models.OrderStatusProcessing, 0, 0); err != nil {
+		t.Fatalf("UpdateOrderAccrual() error = %v", err)
+	}
+	if err := b.Orders.UpdateOrderAccrual(ctx, hi, models.OrderStatusProcessed, 10, 0); err != nil {
+		t.Fatalf("UpdateOrderAccrual() error = %v", err)
+	}
+	if got := pending(after); !slices.Contains(got, lo) || slices.Contains(got, hi) {
+		t.Errorf("GetPendingOrders() = %v, expected %s but not the processed %s", got, lo, hi)
+	}
+}
+
+func testWithdraw(t *testing.T, b Backend) {
+	ctx := context.Background()
+	user := createUser(t, b)
+	number := createOrder(t, b, user.ID)
+	if err := b.Orders.UpdateOrderAccrual(ctx, number, models.OrderStatusProcessed, 500, 0); err != nil {
+		t.Fatalf("UpdateOrderAccrual() error = %v", err)
+	}
+
+	withdrawals, err := b.Users.GetUserWithdrawals(ctx, user.ID)
+	if err != nil || withdrawals == nil || len(withdrawals) != 0 {
+		t.Errorf("GetUserWithdrawals(none) = %#v, %v, expected an empty list", withdrawals, err)
+	}
+
+	first, second := uniqueNumber(), uniqueNumber()
+	if err := b.Users.Withdraw(ctx, user.ID, first, 100); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := b.Users.Withdraw(ctx, user.ID, second, 150); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	expectBalance(t, b, user.ID, 250, 250)
+
+	expectErr(t, "Withdraw(more than the balance)", b.Users.Withdraw(ctx, user.ID, uniqueNumber(), 1000), service.ErrInsufficientFunds)
+	expectErr(t, "Withdraw(duplicate order)", b.Users.Withdraw(ctx, user.ID, first, 10), service.ErrInvalidOrder)
+	if err := b.Users.Withdraw(ctx, user.ID, uniqueNumber(), 0); service.KindOf(err) != service.KindValidation {
+		t.Errorf("Withdraw(0) error = %v, expected a validation error", err)
+	}
+	expectBalance(t, b, user.ID, 250, 250)
+
+	withdrawals, err = b.Users.GetUserWithdrawals(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserWithdrawals() error = %v", err)
+	}
+	if len(withdrawals) != 2 || withdrawals[0].OrderNumber != second || withdrawals[1].OrderNumber != first {
+		t.Fatalf("GetUserWithdrawals() = %+v, expected %s then %s", withdrawals, second, first)
+	}
+	if withdrawals[0].Sum != 150 || withdrawals[0].ProcessedAt.IsZero() {
+		t.Errorf("GetUserWithdrawals()[0] = %+v, expected 150 points with a time", withdrawals[0])
+	}
+}
+
+// testCents credits and spends amounts that binary floating point cannot
+// represent: balances must stay exact to the cent, as NUMERIC(12,2) keeps
+// them, or the last withdrawal is refused for a fraction of a cent.
+func testCents(t *testing.T, b Backend) {
+	ctx := context.Background()
+	user := createUser(t, b)
+	number := createOrder(t, b, user.ID)
+	if err := b.Orders.UpdateOrderAccrual(ctx, number, models.OrderStatusProcessed, 0.1, 0.2); err != nil {
+		t.Fatalf("UpdateOrderAccrual() error = %v", err)
+	}
+	expectBalance(t, b, user.ID, 0.3, 0)
+
+	order, err := b.Orders.GetOrder(ctx, number)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if order.Accrual != 0.1 || order.Bonus != 0.2 {
+		t.Errorf("GetOrder() = %v+%v, expected 0.1+0.2", order.Accrual, order.Bonus)
+	}
+
+	for _, sum := range []float64{0.1, 0.2} {
+		if err := b.Users.Withdraw(ctx, user.ID, uniqueNumber(), sum); err != nil {
+			t.Fatalf("Withdraw(%v) error = %v", sum, err)
+		}
+	}
+	expectBalance(t, b, user.ID, 0, 0.3)
+
+	withdrawals, err := b.Users.GetUserWithdrawals(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserWithdrawals() error = %v", err)
+	}
+	if len(withdrawals) != 2 || withdrawals[0].Sum != 0.2 || withdrawals[1].Sum != 0.1 {
+		t.Errorf("GetUserWithdrawals() = %+v, expected 0.2 then 0.1", withdrawals)
+	}
+}
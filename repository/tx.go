@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// TxManager runs a unit of work in a single transaction. Repository calls
+// made with the context passed to fn take part in it. fn may be run more than
+// once when the transaction is retried after a serialization failure.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type VoucherRepository interface {
+	CreateBatch(ctx context.Context, batch models.VoucherBatch, codeHashes []string) error
+	// RedeemVoucher marks the voucher redeemed by userID and credits its value
+	// in one transaction; a voucher is redeemed at most once.
+	RedeemVoucher(ctx context.Context, codeHash, userID string, now time.Time) (*models.VoucherRedemption, error)
+	BatchReports(ctx context.Context, now time.Time) ([]models.VoucherBatchReport, error)
+}
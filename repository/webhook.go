@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint models.WebhookEndpoint, secret string) error
+	CountEndpoints(ctx context.Context, owner models.WebhookOwner) (int, error)
+	ListEndpoints(ctx context.Context, owner models.WebhookOwner) ([]models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error
+	// EnableEndpoint re-enables a disabled endpoint and clears its failure
+	// count; deliveries held back while it was disabled are sent again.
+	EnableEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error
+	DisableEndpoint(ctx context.Context, id, reason string, at time.Time) error
+
+	// EnqueueDeliveries creates a pending delivery of event for every enabled
+	// endpoint subscribed to it. Enqueuing the same event again is a no-op.
+	EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte, at time.Time) (int, error)
+	// ListDeliveries returns an endpoint's deliveries newest first, those with
+	// an ID below beforeID when it is set.
+	ListDeliveries(ctx context.Context, owner models.WebhookOwner, endpointID string, beforeID int64, limit int) ([]models.WebhookDelivery, error)
+	// ReplayDelivery queues a delivery to be sent again at, whatever its status,
+	// with a fresh retry schedule.
+	ReplayDelivery(ctx context.Context, owner models.WebhookOwner, endpointID string, id int64, at time.Time) error
+
+	// ClaimDeliveries returns up to limit pending deliveries due at now, oldest
+	// first, and never one queued behind a delivery to the same endpoint that
+	// waits for a retry. The claimed deliveries are not due again until
+	// leaseUntil, so they can be sent after the claim commits. It must run in
+	// a unit of work; ok is false when another worker holds the queue for the
+	// rest of the transaction.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) (deliveries []models.WebhookDelivery, ok bool, err error)
+	// ReleaseDeliveries makes claimed deliveries that are still pending due at
+	// at again without counting an attempt.
+	ReleaseDeliveries(ctx context.Context, ids []int64, at time.Time) error
+	MarkDelivered(ctx context.Context, delivery models.WebhookDelivery, statusCode int, at time.Time) error
+	// MarkDeliveryFailed records a failed attempt, to be retried at retryAt or,
+	// when retryAt is nil, not at all. It returns the endpoint's consecutive
+	// failures including this one.
+	MarkDeliveryFailed(ctx context.Context, delivery models.WebhookDelivery, statusCode int, reason string, retryAt *time.Time) (int, error)
+}
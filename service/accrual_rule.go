@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+)
+
+// BonusCalculator works out the promotional bonus for an order being credited.
+type BonusCalculator interface {
+	Bonus(ctx context.Context, order models.Order, accrual float64) (float64, error)
+}
+
+// maxMultiplier is the exclusive upper bound of a rule multiplier; the column
+// is NUMERIC(6,3), so anything from 1000 up would overflow on insert.
+const maxMultiplier = 1000
+
+type AccrualRuleService struct {
+	repo repository.AccrualRuleRepository
+	now  func() time.Time
+}
+
+func NewAccrualRuleService(repo repository.AccrualRuleRepository) *AccrualRuleService {
+	return &AccrualRuleService{repo: repo, now: time.Now}
+}
+
+func (s *AccrualRuleService) CreateRule(ctx context.Context, req models.CreateAccrualRuleRequest) (*models.AccrualRule, error) {
+	rule := models.AccrualRule{
+		ID:         uuid.New().String(),
+		Name:       strings.TrimSpace(req.Name),
+		Multiplier: req.Multiplier,
+		Bonus:      req.Bonus,
+		MinAccrual: req.MinAccrual,
+		MaxBonus:   req.MaxBonus,
+		Stackable:  req.Stackable,
+		EndsAt:     req.EndsAt,
+		CreatedAt:  s.now(),
+	}
+
+	rule.StartsAt = rule.CreatedAt
+	if req.StartsAt != nil {
+		rule.StartsAt = *req.StartsAt
+	}
+	if rule.Multiplier == 0 {
+		rule.Multiplier = 1
+	}
+
+	switch {
+	case rule.Name == "",
+		rule.Multiplier < 1,
+		rule.Multiplier >= maxMultiplier,
+		rule.Bonus < 0,
+		rule.Multiplier == 1 && rule.Bonus == 0,
+		rule.MinAccrual < 0,
+		rule.MaxBonus != nil && *rule.MaxBonus <= 0,
+		rule.EndsAt != nil && !rule.EndsAt.After(rule.StartsAt):
+		return nil, ErrInvalidAccrualRule
+	}
+
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *AccrualRuleService) ListRules(ctx context.Context) ([]models.AccrualRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+func (s *AccrualRuleService) DisableRule(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrAccrualRuleNotFound
+	}
+	return s.repo.DisableRule(ctx, id)
+}
+
+// Bonus evaluates the rules that were running when the order was uploaded, so
+// an order placed during a double-points weekend keeps its bonus even if the
+// accrual system scores it later.
+func (s *AccrualRuleService) Bonus(ctx context.Context, order models.Order, accrual float64) (float64, error) {
+	if accrual <= 0 {
+		return 0, nil
+	}
+
+	rules, err := s.repo.RulesAt(ctx, order.UploadedAt)
+	if err != nil {
+		return 0, err
+	}
+	return ApplyAccrualRules(rules, accrual), nil
+}
+
+// ApplyAccrualRules returns the bonus the rules add to a base accrual. Every
+// stackable rule contributes; of the exclusive rules only the largest does.
+func ApplyAccrualRules(rules []models.AccrualRule, accrual float64) float64 {
+	var stacked, exclusive float64
+	for _, rule := range rules {
+		if accrual < rule.MinAccrual {
+			continue
+		}
+
+		bonus := accrual*(rule.Multiplier-1) + rule.Bonus
+		if rule.MaxBonus != nil && bonus > *rule.MaxBonus {
+			bonus = *rule.MaxBonus
+		}
+
+		if rule.Stackable {
+			stacked += bonus
+		} else if bonus > exclusive {
+			exclusive = bonus
+		}
+	}
+	return math.Round((stacked+exclusive)*100) / 100
+}
+
+type AccrualRuleServiceType interface {
+	CreateRule(ctx context.Context, req models.CreateAccrualRuleRequest) (*models.AccrualRule, error)
+	ListRules(ctx context.Context) ([]models.AccrualRule, error)
+	DisableRule(ctx context.Context, id string) error
+}
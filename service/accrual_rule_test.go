@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAccrualRuleRepo struct {
+	rules []models.AccrualRule
+}
+
+func (m *mockAccrualRuleRepo) CreateRule(ctx context.Context, rule models.AccrualRule) error {
+	m.rules = append(m.rules, rule)
+	return nil
+}
+
+func (m *mockAccrualRuleRepo) ListRules(ctx context.Context) ([]models.AccrualRule, error) {
+	return m.rules, nil
+}
+
+func (m *mockAccrualRuleRepo) DisableRule(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockAccrualRuleRepo) RulesAt(ctx context.Context, at time.Time) ([]models.AccrualRule, error) {
+	var active []models.AccrualRule
+	for _, r := range m.rules {
+		if !r.StartsAt.After(at) && (r.EndsAt == nil || r.EndsAt.After(at)) {
+			active = append(active, r)
+		}
+	}
+	return active, nil
+}
+
+func TestApplyAccrualRules(t *testing.T) {
+	cap30 := 30.0
+
+	tests := []struct {
+		name    string
+		rules   []models.AccrualRule
+		accrual float64
+		want    float64
+	}{
+		{"no rules", nil, 100, 0},
+		{"double points", []models.AccrualRule{{Multiplier: 2}}, 100, 100},
+		{"flat bonus over threshold", []models.AccrualRule{{Multiplier: 1, Bonus: 50, MinAccrual: 80}}, 100, 50},
+		{"flat bonus under threshold", []models.AccrualRule{{Multiplier: 1, Bonus: 50, MinAccrual: 200}}, 100, 0},
+		{"capped", []models.AccrualRule{{Multiplier: 2, MaxBonus: &cap30}}, 100, 30},
+		{
+			name: "exclusive rules take the best one",
+			rules: []models.AccrualRule{
+				{Multiplier: 2},
+				{Multiplier: 1.5},
+			},
+			accrual: 100,
+			want:    100,
+		},
+		{
+			name: "stackable rules add to the best exclusive one",
+			rules: []models.AccrualRule{
+				{Multiplier: 2},
+				{Multiplier: 1.5},
+				{Multiplier: 1, Bonus: 50, Stackable: true},
+				{Multiplier: 1.1, Stackable: true},
+			},
+			accrual: 100,
+			want:    160,
+		},
+		{"rounded to cents", []models.AccrualRule{{Multiplier: 1.333}}, 10, 3.33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, ApplyAccrualRules(tt.rules, tt.accrual), 0.001)
+		})
+	}
+}
+
+func TestAccrualRuleService_CreateRule(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		req     models.CreateAccrualRuleRequest
+		wantErr bool
+	}{
+		{"multiplier", models.CreateAccrualRuleRequest{Name: "Double weekend", Multiplier: 2}, false},
+		{"flat bonus", models.CreateAccrualRuleRequest{Name: "Big basket", Bonus: 50, MinAccrual: 500}, false},
+		{"no effect", models.CreateAccrualRuleRequest{Name: "Nothing"}, true},
+		{"multiplier below one", models.CreateAccrualRuleRequest{Name: "Half", Multiplier: 0.5}, true},
+		{"multiplier overflows column", models.CreateAccrualRuleRequest{Name: "Huge", Multiplier: 1000}, true},
+		{"largest multiplier", models.CreateAccrualRuleRequest{Name: "Almost huge", Multiplier: 999.999}, false},
+		{"no name", models.CreateAccrualRuleRequest{Multiplier: 2}, true},
+		{"bad window", models.CreateAccrualRuleRequest{Name: "Past", Multiplier: 2, EndsAt: &earlier}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAccrualRuleService(&mockAccrualRuleRepo{})
+			svc.now = func() time.Time { return now }
+
+			rule, err := svc.CreateRule(context.Background(), tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAccrualRule)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, now, rule.StartsAt)
+			assert.GreaterOrEqual(t, rule.Multiplier, 1.0)
+		})
+	}
+}
+
+func TestAccrualSyncService_Rescore_Bonus(t *testing.T) {
+	weekend := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+	weekendEnd := weekend.Add(48 * time.Hour)
+
+	rules := &mockAccrualRuleRepo{rules: []models.AccrualRule{
+		{Name: "Double weekend", Multiplier: 2, StartsAt: weekend, EndsAt: &weekendEnd},
+	}}
+
+	tests := []struct {
+		name       string
+		uploadedAt time.Time
+		status     string
+		wantBonus  float64
+	}{
+		{"uploaded during the weekend", weekend.Add(time.Hour), "PROCESSED", 500},
+		{"uploaded before the weekend", weekend.Add(-time.Hour), "PROCESSED", 0},
+		{"not processed yet", weekend.Add(time.Hour), "PROCESSING", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockOrderRepo{orders: map[string]models.Order{
+				"79927398713": {Number: "79927398713", Status: models.OrderStatusNew, UploadedAt: tt.uploadedAt},
+			}}
+			svc := NewAccrualSyncService(repo, &mockLoyaltyService{resp: &models.OrderAccrualResponse{Status: tt.status, Accrual: 500}})
+			svc.SetBonuses(NewAccrualRuleService(rules))
+
+			res, err := svc.Rescore(context.Background(), "79927398713", true)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBonus, res.Bonus)
+			assert.Equal(t, 500.0, repo.updates[0].Accrual)
+			assert.Equal(t, tt.wantBonus, repo.updates[0].Bonus)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+)
+
+const accrualSyncBatchSize = 100
+
+type AccrualSyncService struct {
+	orders    repository.OrderRepository
+	loyalty   LoyaltyService
+	campaigns CampaignGranter
+	bonuses   BonusCalculator
+	referrals ReferralRewarder
+	tx        repository.TxManager
+	logger    *slog.Logger
+}
+
+// ReferralRewarder settles referrals when a member's order is processed.
+type ReferralRewarder interface {
+	RewardFirstOrder(ctx context.Context, userID, orderNumber string) error
+}
+
+func NewAccrualSyncService(orders repository.OrderRepository, loyalty LoyaltyService) *AccrualSyncService {
+	return &AccrualSyncService{orders: orders, loyalty: loyalty, logger: slog.Default()}
+}
+
+func (s *AccrualSyncService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetCampaigns enables first-order grants when an order becomes PROCESSED.
+func (s *AccrualSyncService) SetCampaigns(campaigns CampaignGranter) {
+	s.campaigns = campaigns
+}
+
+// SetBonuses enables promotional accrual rules for processed orders.
+func (s *AccrualSyncService) SetBonuses(bonuses BonusCalculator) {
+	s.bonuses = bonuses
+}
+
+// SetReferrals enables referral rewards when an order becomes PROCESSED.
+func (s *AccrualSyncService) SetReferrals(referrals ReferralRewarder) {
+	s.referrals = referrals
+}
+
+// SetTxManager makes applying a rescore atomic: the order update, the balance
+// correction and first-order rewards commit together, and two concurrent
+// rescores cannot both see the order become PROCESSED.
+func (s *AccrualSyncService) SetTxManager(tx repository.TxManager) {
+	s.tx = tx
+}
+
+type RescoreResult struct {
+	Before  models.Order
+	Status  string
+	Accrual float64
+	Bonus   float64
+}
+
+// Rescore asks the accrual system about an order. When apply is set the new
+// status, accrual and promotional bonus are stored and the member's balance is
+// corrected by the difference; otherwise the result is only reported.
+func (s *AccrualSyncService) Rescore(ctx context.Context, orderNumber string, apply bool) (*RescoreResult, error) {
+	order, err := s.orders.GetOrder(ctx, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.loyalty.GetOrderAccrual(ctx, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RescoreResult{
+		Before:  *order,
+		Status:  OrderStatusFromAccrual(resp.Status),
+		Accrual: resp.Accrual,
+	}
+
+	if s.bonuses != nil && result.Status == models.OrderStatusProcessed {
+		result.Bonus, err = s.bonuses.Bonus(ctx, *order, result.Accrual)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if apply {
+		err := inTx(ctx, s.tx, func(ctx context.Context) error {
+			// Re-read the order in the unit of work: its status decides
+			// whether the first-order rewards are due.
+			current, err := s.orders.GetOrder(ctx, orderNumber)
+			if err != nil {
+				return err
+			}
+			if current.Status == result.Status && current.Accrual == result.Accrual && current.Bonus == result.Bonus {
+				return nil
+			}
+			if err := s.orders.UpdateOrderAccrual(ctx, orderNumber, result.Status, result.Accrual, result.Bonus); err != nil {
+				return err
+			}
+			if current.Status != models.OrderStatusProcessed && result.Status == models.OrderStatusProcessed {
+				return s.orderProcessed(ctx, current.UserID, orderNumber)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (s *AccrualSyncService) orderProcessed(ctx context.Context, userID, orderNumber string) error {
+	if s.campaigns != nil {
+		err := sideEffect(ctx, s.tx, s.logger, "first-order grant failed", s.campaigns.GrantFirstOrder(ctx, userID, orderNumber), "member_id", userID, "order", orderNumber)
+		if err != nil {
+			return err
+		}
+	}
+	if s.referrals != nil {
+		return sideEffect(ctx, s.tx, s.logger, "referral reward failed", s.referrals.RewardFirstOrder(ctx, userID, orderNumber), "member_id", userID, "order", orderNumber)
+	}
+	return nil
+}
+
+// SyncPending rescores and stores one page of NEW and PROCESSING orders
+// numbered after the given one, and returns where the next page starts; an
+// empty cursor means the last page was reached. An order the accrual system
+// fails on is logged and left for the next pass. When the accrual system
+// asks to slow down the page ends early, so the rest is retried from there.
+func (s *AccrualSyncService) SyncPending(ctx context.Context, after string) (string, error) {
+	orders, err := s.orders.GetPendingOrders(ctx, after, accrualSyncBatchSize)
+	if err != nil {
+		return after, err
+	}
+
+	for _, order := range orders {
+		if _, err := s.Rescore(ctx, order.Number, true); err != nil {
+			if errors.Is(err, ErrTooManyReq) || ctx.Err() != nil {
+				return after, err
+			}
+			s.logger.WarnContext(ctx, "order not rescored", "order", order.Number, "error", err)
+		}
+		after = order.Number
+	}
+
+	if len(orders) < accrualSyncBatchSize {
+		return "", nil
+	}
+	return after, nil
+}
+
+// Run polls the accrual system for unfinished orders every interval until ctx
+// is done, one page per tick.
+func (s *AccrualSyncService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var cursor string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			next, err := s.SyncPending(ctx, cursor)
+			if err != nil && ctx.Err() == nil {
+				s.logger.WarnContext(ctx, "accrual sync paused", "error", err)
+			}
+			cursor = next
+		}
+	}
+}
+
+// Requeue puts an order that never reached PROCESSED back to NEW so the
+// accrual worker scores it again. Processed orders have been credited and must be re-scored
+// instead.
+func (s *AccrualSyncService) Requeue(ctx context.Context, orderNumber string, apply bool) (*models.Order, error) {
+	order, err := s.orders.GetOrder(ctx, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == models.OrderStatusProcessed {
+		return nil, ErrOrderFinal
+	}
+
+	if apply {
+		if err := s.orders.UpdateOrderAccrual(ctx, orderNumber, models.OrderStatusNew, 0, 0); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// OrderStatusFromAccrual maps accrual system statuses onto order statuses.
+// The accrual system reports REGISTERED for orders it has not started on,
+// which members see as PROCESSING.
+func OrderStatusFromAccrual(status string) string {
+	if status == "REGISTERED" {
+		return models.OrderStatusProcessing
+	}
+	return status
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOrderRepo struct {
+	orders  map[string]models.Order
+	updates []models.Order
+}
+
+func (m *mockOrderRepo) CheckOrderExists(ctx context.Context, orderNumber string) (string, bool, error) {
+	o, ok := m.orders[orderNumber]
+	return o.UserID, ok, nil
+}
+
+func (m *mockOrderRepo) CreateOrder(ctx context.Context, order models.Order) error {
+	m.orders[order.Number] = order
+	return nil
+}
+
+func (m *mockOrderRepo) GetOrdersByUser(ctx context.Context, userID string) ([]models.Order, error) {
+	var orders []models.Order
+	for _, o := range m.orders {
+		if o.UserID == userID {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+func (m *mockOrderRepo) GetOrder(ctx context.Context, orderNumber string) (*models.Order, error) {
+	o, ok := m.orders[orderNumber]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	return &o, nil
+}
+
+func (m *mockOrderRepo) GetPendingOrders(ctx context.Context, after string, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	for _, o := range m.orders {
+		if (o.Status == models.OrderStatusNew || o.Status == models.OrderStatusProcessing) && o.Number > after {
+			orders = append(orders, o)
+		}
+	}
+	slices.SortFunc(orders, func(a, b models.Order) int { return strings.Compare(a.Number, b.Number) })
+	return orders[:min(len(orders), limit)], nil
+}
+
+func (m *mockOrderRepo) UpdateOrderAccrual(ctx context.Context, orderNumber, status string, accrual, bonus float64) error {
+	m.updates = append(m.updates, models.Order{Number: orderNumber, Status: status, Accrual: accrual, Bonus: bonus})
+	return nil
+}
+
+type mockLoyaltyService struct {
+	resp *models.OrderAccrualResponse
+	err  error
+}
+
+func (m *mockLoyaltyService) GetOrderAccrual(ctx context.Context, orderNumber string) (*models.OrderAccrualResponse, error) {
+	return m.resp, m.err
+}
+
+func TestAccrualSyncService_Rescore(t *testing.T) {
+	tests := []struct {
+		name        string
+		accrual     *models.OrderAccrualResponse
+		apply       bool
+		wantStatus  string
+		wantUpdates int
+	}{
+		{"processed applied", &models.OrderAccrualResponse{Status: "PROCESSED", Accrual: 500}, true, models.OrderStatusProcessed, 1},
+		{"registered maps to processing", &models.OrderAccrualResponse{Status: "REGISTERED"}, true, models.OrderStatusProcessing, 1},
+		{"dry run", &models.OrderAccrualResponse{Status: "PROCESSED", Accrual: 500}, false, models.OrderStatusProcessed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockOrderRepo{orders: map[string]models.Order{
+				"79927398713": {Number: "79927398713", Status: models.OrderStatusNew},
+			}}
+			svc := NewAccrualSyncService(repo, &mockLoyaltyService{resp: tt.accrual})
+
+			res, err := svc.Rescore(context.Background(), "79927398713", tt.apply)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.Status)
+			assert.Equal(t, models.OrderStatusNew, res.Before.Status)
+			assert.Len(t, repo.updates, tt.wantUpdates)
+		})
+	}
+}
+
+func TestAccrualSyncService_Requeue(t *testing.T) {
+	repo := &mockOrderRepo{orders: map[string]models.Order{
+		"79927398713":      {Number: "79927398713", Status: models.OrderStatusInvalid},
+		"4532015112830366": {Number: "4532015112830366", Status: models.OrderStatusProcessed, Accrual: 10},
+	}}
+	svc := NewAccrualSyncService(repo, nil)
+
+	_, err := svc.Requeue(context.Background(), "79927398713", true)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Order{{Number: "79927398713", Status: models.OrderStatusNew}}, repo.updates)
+
+	_, err = svc.Requeue(context.Background(), "4532015112830366", true)
+	assert.ErrorIs(t, err, ErrOrderFinal)
+
+	_, err = svc.Requeue(context.Background(), "0", true)
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestAccrualSyncService_SyncPending(t *testing.T) {
+	repo := &mockOrderRepo{orders: map[string]models.Order{
+		"79927398713":      {Number: "79927398713", Status: models.OrderStatusNew},
+		"4532015112830366": {Number: "4532015112830366", Status: models.OrderStatusProcessing},
+		"12345678903":      {Number: "12345678903", Status: models.OrderStatusProcessed, Accrual: 10},
+	}}
+	loyalty := &mockLoyaltyService{resp: &models.OrderAccrualResponse{Status: "PROCESSED", Accrual: 500}}
+	svc := NewAccrualSyncService(repo, loyalty)
+
+	next, err := svc.SyncPending(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Equal(t, []models.Order{
+		{Number: "4532015112830366", Status: models.OrderStatusProcessed, Accrual: 500},
+		{Number: "79927398713", Status: models.OrderStatusProcessed, Accrual: 500},
+	}, repo.updates)
+}
+
+func TestAccrualSyncService_SyncPendingRateLimited(t *testing.T) {
+	repo := &mockOrderRepo{orders: map[string]models.Order{
+		"79927398713": {Number: "79927398713", Status: models.OrderStatusNew},
+	}}
+	svc := NewAccrualSyncService(repo, &mockLoyaltyService{err: ErrTooManyReq})
+
+	next, err := svc.SyncPending(context.Background(), "1")
+	assert.ErrorIs(t, err, ErrTooManyReq)
+	assert.Equal(t, "1", next)
+	assert.Empty(t, repo.updates)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+)
+
+const userSearchLimit = 50
+
+var roles = []string{models.RoleUser, models.RoleSupport, models.RoleAdmin}
+
+type AdminService struct {
+	repo   repository.AdminRepository
+	users  repository.UserRepository
+	orders repository.OrderRepository
+}
+
+func NewAdminService(repo repository.AdminRepository, users repository.UserRepository, orders repository.OrderRepository) *AdminService {
+	return &AdminService{repo: repo, users: users, orders: orders}
+}
+
+func (s *AdminService) SearchUsers(ctx context.Context, login string) ([]models.User, error) {
+	login = strings.TrimSpace(login)
+	if login == "" {
+		return []models.User{}, nil
+	}
+	return s.repo.SearchUsers(ctx, login, userSearchLimit)
+}
+
+func (s *AdminService) GetAccount(ctx context.Context, userID string) (*models.UserAccount, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, withdrawn, err := s.users.GetUserPoints(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserAccount{
+		User:    *user,
+		Balance: models.BalanceResponse{Current: current, Withdrawn: withdrawn},
+	}, nil
+}
+
+func (s *AdminService) GetOrders(ctx context.Context, userID string) ([]models.Order, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.orders.GetOrdersByUser(ctx, userID)
+}
+
+func (s *AdminService) GetWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.users.GetUserWithdrawals(ctx, userID)
+}
+
+func (s *AdminService) GetAdjustments(ctx context.Context, userID string) ([]models.BalanceAdjustment, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAdjustments(ctx, userID)
+}
+
+func (s *AdminService) SetBlocked(ctx context.Context, userID string, blocked bool) error {
+	if uuid.Validate(userID) != nil {
+		return ErrUserNotFound
+	}
+	return s.repo.SetUserBlocked(ctx, userID, blocked)
+}
+
+func (s *AdminService) SetRole(ctx context.Context, userID, role string) error {
+	if !slices.Contains(roles, role) {
+		return ErrInvalidRole
+	}
+	if uuid.Validate(userID) != nil {
+		return ErrUserNotFound
+	}
+	return s.repo.SetUserRole(ctx, userID, role)
+}
+
+// AdjustBalance credits (positive amount) or debits (negative amount) a
+// member's balance. actor identifies who made the change and is stored with
+// the mandatory reason.
+func (s *AdminService) AdjustBalance(ctx context.Context, actor, userID string, amount float64, reason string) (*models.BalanceAdjustment, error) {
+	reason = strings.TrimSpace(reason)
+	if amount == 0 || reason == "" {
+		return nil, ErrInvalidAdjustment
+	}
+	if uuid.Validate(userID) != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return s.repo.AdjustBalance(ctx, models.BalanceAdjustment{
+		UserID: userID,
+		Amount: amount,
+		Reason: reason,
+		Actor:  actor,
+	})
+}
+
+// UserStatus returns the member's current role and whether they are blocked,
+// so the auth middleware can act on changes made after a session's token was
+// issued.
+func (s *AdminService) UserStatus(ctx context.Context, userID string) (string, bool, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return "", false, err
+	}
+	return user.Role, user.BlockedAt != nil, nil
+}
+
+// getUser treats malformed IDs as unknown users instead of letting Postgres
+// reject them with a type error.
+func (s *AdminService) getUser(ctx context.Context, userID string) (*models.User, error) {
+	if uuid.Validate(userID) != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+type AdminServiceType interface {
+	SearchUsers(ctx context.Context, login string) ([]models.User, error)
+	GetAccount(ctx context.Context, userID string) (*models.UserAccount, error)
+	GetOrders(ctx context.Context, userID string) ([]models.Order, error)
+	GetWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error)
+	GetAdjustments(ctx context.Context, userID string) ([]models.BalanceAdjustment, error)
+	SetBlocked(ctx context.Context, userID string, blocked bool) error
+	SetRole(ctx context.Context, userID, role string) error
+	AdjustBalance(ctx context.Context, actor, userID string, amount float64, reason string) (*models.BalanceAdjustment, error)
+}
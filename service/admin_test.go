@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const adminTestUserID = "6f1c2a4e-8b7d-4c39-9a52-0d3e1f6b7a81"
+
+type mockAdminRepo struct {
+	users       map[string]models.User
+	adjustments []models.BalanceAdjustment
+	roles       map[string]string
+}
+
+func (m *mockAdminRepo) SearchUsers(ctx context.Context, login string, limit int) ([]models.User, error) {
+	return nil, nil
+}
+
+func (m *mockAdminRepo) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	u, ok := m.users[userID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (m *mockAdminRepo) SetUserBlocked(ctx context.Context, userID string, blocked bool) error {
+	return nil
+}
+
+func (m *mockAdminRepo) SetUserRole(ctx context.Context, userID, role string) error {
+	if m.roles == nil {
+		m.roles = make(map[string]string)
+	}
+	m.roles[userID] = role
+	return nil
+}
+
+func (m *mockAdminRepo) AdjustBalance(ctx context.Context, adj models.BalanceAdjustment) (*models.BalanceAdjustment, error) {
+	m.adjustments = append(m.adjustments, adj)
+	return &adj, nil
+}
+
+func (m *mockAdminRepo) GetAdjustments(ctx context.Context, userID string) ([]models.BalanceAdjustment, error) {
+	return m.adjustments, nil
+}
+
+func TestAdminService_AdjustBalance(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		amount  float64
+		reason  string
+		wantErr error
+	}{
+		{"credit", adminTestUserID, 50, "goodwill", nil},
+		{"debit", adminTestUserID, -20, "duplicate accrual", nil},
+		{"zero amount", adminTestUserID, 0, "noop", ErrInvalidAdjustment},
+		{"missing reason", adminTestUserID, 10, "   ", ErrInvalidAdjustment},
+		{"malformed user id", "42", 10, "goodwill", ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockAdminRepo{}
+			svc := NewAdminService(repo, &mockUserRepo{}, nil)
+
+			adj, err := svc.AdjustBalance(context.Background(), "user:admin", tt.userID, tt.amount, tt.reason)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, repo.adjustments)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "user:admin", adj.Actor)
+			assert.Equal(t, tt.amount, adj.Amount)
+		})
+	}
+}
+
+func TestAdminService_SetRole(t *testing.T) {
+	repo := &mockAdminRepo{}
+	svc := NewAdminService(repo, &mockUserRepo{}, nil)
+
+	assert.NoError(t, svc.SetRole(context.Background(), adminTestUserID, models.RoleSupport))
+	assert.Equal(t, models.RoleSupport, repo.roles[adminTestUserID])
+
+	assert.ErrorIs(t, svc.SetRole(context.Background(), adminTestUserID, "root"), ErrInvalidRole)
+}
+
+func TestAdminService_GetAccountAndBlocked(t *testing.T) {
+	blockedAt := time.Now()
+	repo := &mockAdminRepo{users: map[string]models.User{
+		adminTestUserID: {ID: adminTestUserID, Login: "alice", Role: models.RoleSupport, BlockedAt: &blockedAt},
+	}}
+	svc := NewAdminService(repo, &mockUserRepo{}, nil)
+
+	account, err := svc.GetAccount(context.Background(), adminTestUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", account.User.Login)
+	assert.Equal(t, 100.0, account.Balance.Current)
+
+	role, blocked, err := svc.UserStatus(context.Background(), adminTestUserID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleSupport, role)
+	assert.True(t, blocked)
+
+	_, err = svc.GetAccount(context.Background(), "not-a-uuid")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix       = "gm_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+var allScopes = append(slices.Clone(models.UserScopes), models.ScopeAdmin)
+
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateUserKey issues a key that acts on behalf of userID. Members may only
+// grant the scopes listed in models.UserScopes.
+func (s *APIKeyService) CreateUserKey(ctx context.Context, userID, name string, scopes []string) (*models.CreatedAPIKey, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(models.UserScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	return s.createKey(ctx, models.APIKey{UserID: userID, Name: name, Scopes: scopes})
+}
+
+// CreateMerchantKey issues a key for a store integration, limited to the
+// scopes listed in models.MerchantScopes.
+func (s *APIKeyService) CreateMerchantKey(ctx context.Context, merchantID, name string, scopes []string) (*models.CreatedAPIKey, error) {
+	if uuid.Validate(merchantID) != nil {
+		return nil, ErrMerchantNotFound
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.MerchantScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	key, err := s.createKey(ctx, models.APIKey{MerchantID: merchantID, Name: name, Scopes: scopes})
+	if errors.Is(err, ErrInvalidReference) {
+		return nil, ErrMerchantNotFound
+	}
+	return key, err
+}
+
+// CreateServiceKey issues a key that is bound to neither a member nor a store,
+// for internal callers such as admin tooling. It is the only kind of key that
+// may hold the admin scope, so only admins may issue it.
+func (s *APIKeyService) CreateServiceKey(ctx context.Context, name string, scopes []string) (*models.CreatedAPIKey, error) {
+	return s.createKey(ctx, models.APIKey{Name: name, Scopes: scopes})
+}
+
+func (s *APIKeyService) createKey(ctx context.Context, key models.APIKey) (*models.CreatedAPIKey, error) {
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return nil, ErrAPIKeyNameRequired
+	}
+	if len(key.Scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, scope := range key.Scopes {
+		if !slices.Contains(allScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(raw)
+
+	key.ID = uuid.New().String()
+	key.Prefix = secret[:apiKeyPrefixLength]
+	key.Scopes = slices.Compact(slices.Sorted(slices.Values(key.Scopes)))
+	key.CreatedAt = time.Now()
+
+	if err := s.repo.CreateAPIKey(ctx, key, HashToken(secret)); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, HashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, userID)
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, userID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrAPIKeyNotFound
+	}
+	return s.repo.RevokeAPIKey(ctx, userID, id)
+}
+
+type APIKeyServiceType interface {
+	CreateUserKey(ctx context.Context, userID, name string, scopes []string) (*models.CreatedAPIKey, error)
+	CreateMerchantKey(ctx context.Context, merchantID, name string, scopes []string) (*models.CreatedAPIKey, error)
+	CreateServiceKey(ctx context.Context, name string, scopes []string) (*models.CreatedAPIKey, error)
+	ListKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, userID, id string) error
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPIKeyRepo struct {
+	keys    map[string]models.APIKey
+	touched []string
+}
+
+func newMockAPIKeyRepo() *mockAPIKeyRepo {
+	return &mockAPIKeyRepo{keys: make(map[string]models.APIKey)}
+}
+
+func (m *mockAPIKeyRepo) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) error {
+	m.keys[keyHash] = key
+	return nil
+}
+
+func (m *mockAPIKeyRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, ok := m.keys[keyHash]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return &key, nil
+}
+
+func (m *mockAPIKeyRepo) TouchAPIKey(ctx context.Context, id string) error {
+	m.touched = append(m.touched, id)
+	return nil
+}
+
+func (m *mockAPIKeyRepo) ListAPIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return nil, nil
+}
+
+func (m *mockAPIKeyRepo) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	return nil
+}
+
+func TestAPIKeyService_CreateUserKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyName string
+		scopes  []string
+		wantErr bool
+	}{
+		{"single scope", "ci", []string{models.ScopeOrdersRead}, false},
+		{"duplicate scopes", "ci", []string{models.ScopeBalanceRead, models.ScopeBalanceRead}, false},
+		{"admin not allowed", "ci", []string{models.ScopeAdmin}, true},
+		{"unknown scope", "ci", []string{"orders:delete"}, true},
+		{"no scopes", "ci", nil, true},
+		{"empty name", " ", []string{models.ScopeOrdersRead}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockAPIKeyRepo()
+			svc := NewAPIKeyService(repo)
+
+			created, err := svc.CreateUserKey(context.Background(), "user-1", tt.keyName, tt.scopes)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, repo.keys)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+			assert.Equal(t, "user-1", created.UserID)
+
+			stored, ok := repo.keys[HashToken(created.Key)]
+			assert.True(t, ok, "key must be stored by hash only")
+			assert.Len(t, stored.Scopes, 1)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateMerchantKey(t *testing.T) {
+	merchantID := uuid.NewString()
+
+	tests := []struct {
+		name       string
+		merchantID string
+		scopes     []string
+		wantErr    error
+	}{
+		{"orders and webhooks", merchantID, []string{models.ScopeOrdersWrite, models.ScopeWebhooks}, nil},
+		{"admin not allowed", merchantID, []string{models.ScopeAdmin}, ErrInvalidScope},
+		{"member scope not allowed", merchantID, []string{models.ScopeBalanceWrite}, ErrInvalidScope},
+		{"malformed merchant", "merchant-1", []string{models.ScopeOrdersWrite}, ErrMerchantNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockAPIKeyRepo()
+			svc := NewAPIKeyService(repo)
+
+			created, err := svc.CreateMerchantKey(context.Background(), tt.merchantID, "pos", tt.scopes)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, repo.keys)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.merchantID, created.MerchantID)
+			assert.Empty(t, created.UserID)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateServiceKey(t *testing.T) {
+	repo := newMockAPIKeyRepo()
+	svc := NewAPIKeyService(repo)
+
+	created, err := svc.CreateServiceKey(context.Background(), "ops", []string{models.ScopeAdmin})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.ScopeAdmin}, created.Scopes)
+	assert.Empty(t, created.UserID)
+	assert.Empty(t, created.MerchantID)
+
+	_, err = svc.CreateServiceKey(context.Background(), "ops", []string{"orders:delete"})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	repo := newMockAPIKeyRepo()
+	svc := NewAPIKeyService(repo)
+
+	merchantID := uuid.NewString()
+	created, err := svc.CreateMerchantKey(context.Background(), merchantID, "pos", []string{models.ScopeOrdersWrite})
+	assert.NoError(t, err)
+
+	key, err := svc.AuthenticateAPIKey(context.Background(), created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, merchantID, key.MerchantID)
+	assert.Equal(t, []string{created.ID}, repo.touched)
+
+	_, err = svc.AuthenticateAPIKey(context.Background(), created.Key+"x")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	_, err = svc.AuthenticateAPIKey(context.Background(), "not-a-key")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
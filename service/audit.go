@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditRecorder is what handlers need to write the audit trail.
+type AuditRecorder interface {
+	Record(ctx context.Context, event models.AuditEvent)
+}
+
+type AuditService struct {
+	repo      repository.AuditRepository
+	retention time.Duration
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+// NewAuditService keeps events for retention; zero keeps them forever.
+func NewAuditService(repo repository.AuditRepository, retention time.Duration) *AuditService {
+	return &AuditService{repo: repo, retention: retention, now: time.Now, logger: slog.Default()}
+}
+
+func (s *AuditService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// Record appends event to the trail. A request is not failed because its
+// audit entry could not be written, but the loss is logged with the event so
+// it can be reconstructed.
+func (s *AuditService) Record(ctx context.Context, event models.AuditEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = s.now()
+	}
+	logFailure(ctx, s.logger, "audit event not recorded", s.repo.AppendAuditEvent(ctx, event),
+		"action", event.Action,
+		"outcome", event.Outcome,
+		"actor", event.Actor,
+		"subject", event.Subject,
+	)
+}
+
+func (s *AuditService) ListEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	switch filter.Outcome {
+	case "", models.AuditOutcomeSuccess, models.AuditOutcomeFailure:
+	default:
+		return nil, ErrInvalidAuditFilter
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return nil, ErrInvalidAuditFilter
+	}
+	if filter.Limit < 0 || filter.BeforeID < 0 {
+		return nil, ErrInvalidAuditFilter
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	return s.repo.ListAuditEvents(ctx, filter)
+}
+
+// PurgeExpired deletes events older than the retention period.
+func (s *AuditService) PurgeExpired(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.PurgeAuditEvents(ctx, s.now().Add(-s.retention))
+}
+
+// RunRetention purges expired events every interval until ctx is done.
+func (s *AuditService) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.PurgeExpired(ctx)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "audit retention failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				s.logger.InfoContext(ctx, "audit retention purged events", "count", n)
+			}
+		}
+	}
+}
+
+type AuditServiceType interface {
+	ListEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditRepo struct {
+	appendErr error
+	appended  []models.AuditEvent
+	filter    models.AuditFilter
+	cutoff    time.Time
+}
+
+func (m *mockAuditRepo) AppendAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	m.appended = append(m.appended, event)
+	return m.appendErr
+}
+
+func (m *mockAuditRepo) ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	m.filter = filter
+	return nil, nil
+}
+
+func (m *mockAuditRepo) PurgeAuditEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	m.cutoff = cutoff
+	return 3, nil
+}
+
+func TestAuditService_Record(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	repo := &mockAuditRepo{appendErr: errors.New("connection reset")}
+	svc := NewAuditService(repo, time.Hour)
+	svc.now = func() time.Time { return now }
+
+	// A storage failure is only logged; the caller's request goes on.
+	svc.Record(context.Background(), models.AuditEvent{Action: models.AuditActionLogin, Outcome: models.AuditOutcomeSuccess})
+
+	if assert.Len(t, repo.appended, 1) {
+		assert.Equal(t, now, repo.appended[0].OccurredAt)
+	}
+}
+
+func TestAuditService_ListEvents(t *testing.T) {
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		filter    models.AuditFilter
+		wantErr   bool
+		wantLimit int
+	}{
+		{name: "default limit", filter: models.AuditFilter{}, wantLimit: defaultAuditLimit},
+		{name: "limit capped", filter: models.AuditFilter{Limit: 5000}, wantLimit: maxAuditLimit},
+		{name: "outcome filter", filter: models.AuditFilter{Outcome: models.AuditOutcomeFailure, Limit: 10}, wantLimit: 10},
+		{name: "unknown outcome", filter: models.AuditFilter{Outcome: "maybe"}, wantErr: true},
+		{name: "empty range", filter: models.AuditFilter{From: &from, To: &to}, wantErr: true},
+		{name: "negative limit", filter: models.AuditFilter{Limit: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockAuditRepo{}
+			_, err := NewAuditService(repo, 0).ListEvents(context.Background(), tt.filter)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAuditFilter)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, repo.filter.Limit)
+		})
+	}
+}
+
+func TestAuditService_PurgeExpired(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	repo := &mockAuditRepo{}
+	svc := NewAuditService(repo, 30*24*time.Hour)
+	svc.now = func() time.Time { return now }
+	n, err := svc.PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, now.Add(-30*24*time.Hour), repo.cutoff)
+
+	repo = &mockAuditRepo{}
+	n, err = NewAuditService(repo, 0).PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	assert.True(t, repo.cutoff.IsZero(), "zero retention keeps everything")
+}
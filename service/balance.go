@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"regexp"
 
 	"github.com/Guldana11/gophermart/models"
@@ -9,13 +10,24 @@ import (
 )
 
 type BalanceService struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	observer WithdrawalObserver
+}
+
+// WithdrawalObserver records withdrawal attempts. reason is empty for
+// withdrawals that went through.
+type WithdrawalObserver interface {
+	ObserveWithdrawal(reason string)
 }
 
 func NewBalanceService(repo repository.UserRepository) *BalanceService {
 	return &BalanceService{repo: repo}
 }
 
+func (s *BalanceService) SetObserver(observer WithdrawalObserver) {
+	s.observer = observer
+}
+
 func (s *BalanceService) GetUserBalance(ctx context.Context, userID string) (current float64, withdrawn float64, err error) {
 	return s.repo.GetUserPoints(ctx, userID)
 }
@@ -23,7 +35,14 @@ func (s *BalanceService) GetUserBalance(ctx context.Context, userID string) (cur
 var orderRegexp = regexp.MustCompile(`^\d{1,20}$`)
 
 func (s *BalanceService) Withdraw(ctx context.Context, userID string, order string, sum float64) error {
+	err := s.withdraw(ctx, userID, order, sum)
+	if s.observer != nil {
+		s.observer.ObserveWithdrawal(withdrawalFailureReason(err))
+	}
+	return err
+}
 
+func (s *BalanceService) withdraw(ctx context.Context, userID string, order string, sum float64) error {
 	if order == "" || !orderRegexp.MatchString(order) {
 		return ErrInvalidOrder
 	}
@@ -34,6 +53,19 @@ func (s *BalanceService) Withdraw(ctx context.Context, userID string, order stri
 	return s.repo.Withdraw(ctx, userID, order, sum)
 }
 
+func withdrawalFailureReason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, ErrInvalidOrder):
+		return "invalid_order"
+	default:
+		return KindOf(err).String()
+	}
+}
+
 func (s *BalanceService) GetWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error) {
 
 	withdrawals, err := s.repo.GetUserWithdrawals(ctx, userID)
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingWithdrawalObserver struct {
+	reasons []string
+}
+
+func (r *recordingWithdrawalObserver) ObserveWithdrawal(reason string) {
+	r.reasons = append(r.reasons, reason)
+}
+
+func TestBalanceService_Withdraw_ObservesReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		order  string
+		repo   error
+		reason string
+	}{
+		{name: "success", order: "79927398713", reason: ""},
+		{name: "insufficient funds", order: "79927398713", repo: ErrInsufficientFunds, reason: "insufficient_funds"},
+		{name: "bad order number", order: "abc", reason: "invalid_order"},
+		{name: "storage failure", order: "79927398713", repo: errors.New("connection reset"), reason: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockUserRepo{
+				WithdrawPointsFunc: func(ctx context.Context, userID, order string, sum float64) error {
+					return tt.repo
+				},
+			}
+			observer := &recordingWithdrawalObserver{}
+			svc := NewBalanceService(repo)
+			svc.SetObserver(observer)
+
+			_ = svc.Withdraw(context.Background(), "user-1", tt.order, 10)
+			assert.Equal(t, []string{tt.reason}, observer.reasons)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+)
+
+var campaignKinds = []string{models.CampaignKindSignup, models.CampaignKindPromo, models.CampaignKindFirstOrder}
+
+// CampaignGranter is what the user and accrual flows need from campaigns.
+type CampaignGranter interface {
+	GrantSignup(ctx context.Context, userID string) error
+	GrantPromos(ctx context.Context, userID string) error
+	GrantFirstOrder(ctx context.Context, userID, orderNumber string) error
+}
+
+type CampaignService struct {
+	repo   repository.CampaignRepository
+	orders repository.OrderRepository
+	now    func() time.Time
+}
+
+func NewCampaignService(repo repository.CampaignRepository, orders repository.OrderRepository) *CampaignService {
+	return &CampaignService{repo: repo, orders: orders, now: time.Now}
+}
+
+func (s *CampaignService) CreateCampaign(ctx context.Context, req models.CreateCampaignRequest) (*models.Campaign, error) {
+	campaign := models.Campaign{
+		ID:         uuid.New().String(),
+		Name:       strings.TrimSpace(req.Name),
+		Kind:       req.Kind,
+		Amount:     req.Amount,
+		EndsAt:     req.EndsAt,
+		PerUserCap: req.PerUserCap,
+		CreatedAt:  s.now(),
+
+		RepeatEverySeconds: req.RepeatEverySeconds,
+	}
+
+	campaign.StartsAt = campaign.CreatedAt
+	if req.StartsAt != nil {
+		campaign.StartsAt = *req.StartsAt
+	}
+	if campaign.PerUserCap == 0 {
+		campaign.PerUserCap = 1
+	}
+
+	if campaign.Name == "" || !slices.Contains(campaignKinds, campaign.Kind) || campaign.Amount <= 0 ||
+		campaign.PerUserCap < 1 || campaign.RepeatEverySeconds < 0 {
+		return nil, ErrInvalidCampaign
+	}
+	if campaign.EndsAt != nil && !campaign.EndsAt.After(campaign.StartsAt) {
+		return nil, ErrInvalidCampaign
+	}
+	// Signing up and placing a first order happen once per member, so only
+	// promos can repeat. A promo pays again only when it says how often, and
+	// repeating only makes sense with a cap above one.
+	if campaign.Kind != models.CampaignKindPromo && (campaign.PerUserCap != 1 || campaign.RepeatEverySeconds != 0) {
+		return nil, ErrInvalidCampaign
+	}
+	if (campaign.PerUserCap > 1) != (campaign.RepeatEverySeconds > 0) {
+		return nil, ErrInvalidCampaign
+	}
+
+	if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (s *CampaignService) ListCampaigns(ctx context.Context) ([]models.Campaign, error) {
+	return s.repo.ListCampaigns(ctx)
+}
+
+func (s *CampaignService) DisableCampaign(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrCampaignNotFound
+	}
+	return s.repo.DisableCampaign(ctx, id)
+}
+
+func (s *CampaignService) GetUserGrants(ctx context.Context, userID string) ([]models.CampaignGrant, error) {
+	if uuid.Validate(userID) != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.GetUserGrants(ctx, userID)
+}
+
+func (s *CampaignService) GrantSignup(ctx context.Context, userID string) error {
+	return s.grant(ctx, models.CampaignKindSignup, userID, "")
+}
+
+// GrantPromos credits every running promo campaign the member has not been
+// granted yet, and repeating ones whose interval has passed since the
+// member's last grant.
+func (s *CampaignService) GrantPromos(ctx context.Context, userID string) error {
+	return s.grant(ctx, models.CampaignKindPromo, userID, "")
+}
+
+// GrantFirstOrder credits first-order campaigns when orderNumber is the only
+// processed order the member has.
+func (s *CampaignService) GrantFirstOrder(ctx context.Context, userID, orderNumber string) error {
+	orders, err := s.orders.GetOrdersByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, o := range orders {
+		if o.Number != orderNumber && o.Status == models.OrderStatusProcessed {
+			return nil
+		}
+	}
+	return s.grant(ctx, models.CampaignKindFirstOrder, userID, orderNumber)
+}
+
+func (s *CampaignService) grant(ctx context.Context, kind, userID, reference string) error {
+	now := s.now()
+	campaigns, err := s.repo.ActiveCampaigns(ctx, kind, now)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range campaigns {
+		_, err := s.repo.GrantCampaign(ctx, models.CampaignGrant{
+			CampaignID: c.ID,
+			UserID:     userID,
+			Amount:     c.Amount,
+			Reference:  reference,
+			CreatedAt:  now,
+		}, c)
+		if err != nil {
+			return fmt.Errorf("campaign %s: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// GrantDue reports whether a member who holds granted grants from c, the last
+// made at last, may be granted it again at now.
+func GrantDue(c models.Campaign, granted int, last *time.Time, now time.Time) bool {
+	if granted == 0 {
+		return true
+	}
+	if c.RepeatEverySeconds <= 0 || granted >= c.PerUserCap || last == nil {
+		return false
+	}
+	return !now.Before(last.Add(time.Duration(c.RepeatEverySeconds) * time.Second))
+}
+
+// logFailure reports a side effect of a flow that has already succeeded. A
+// failed grant or reward must not undo a registration or a login, so it is
+// only logged.
+func logFailure(ctx context.Context, logger *slog.Logger, msg string, err error, args ...any) {
+	if err != nil {
+		logger.ErrorContext(ctx, msg, append(args, "error", err)...)
+	}
+}
+
+type CampaignServiceType interface {
+	CreateCampaign(ctx context.Context, req models.CreateCampaignRequest) (*models.Campaign, error)
+	ListCampaigns(ctx context.Context) ([]models.Campaign, error)
+	DisableCampaign(ctx context.Context, id string) error
+	GetUserGrants(ctx context.Context, userID string) ([]models.CampaignGrant, error)
+}
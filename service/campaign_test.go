@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCampaignRepo struct {
+	campaigns []models.Campaign
+	grants    []models.CampaignGrant
+}
+
+func (m *mockCampaignRepo) CreateCampaign(ctx context.Context, campaign models.Campaign) error {
+	m.campaigns = append(m.campaigns, campaign)
+	return nil
+}
+
+func (m *mockCampaignRepo) ListCampaigns(ctx context.Context) ([]models.Campaign, error) {
+	return m.campaigns, nil
+}
+
+func (m *mockCampaignRepo) DisableCampaign(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockCampaignRepo) ActiveCampaigns(ctx context.Context, kind string, at time.Time) ([]models.Campaign, error) {
+	var active []models.Campaign
+	for _, c := range m.campaigns {
+		if c.Kind == kind && !c.StartsAt.After(at) && (c.EndsAt == nil || c.EndsAt.After(at)) {
+			active = append(active, c)
+		}
+	}
+	return active, nil
+}
+
+func (m *mockCampaignRepo) GrantCampaign(ctx context.Context, grant models.CampaignGrant, campaign models.Campaign) (bool, error) {
+	granted := 0
+	var last *time.Time
+	for _, g := range m.grants {
+		if g.CampaignID == grant.CampaignID && g.UserID == grant.UserID {
+			granted++
+			last = &g.CreatedAt
+		}
+	}
+	if !GrantDue(campaign, granted, last, grant.CreatedAt) {
+		return false, nil
+	}
+	m.grants = append(m.grants, grant)
+	return true, nil
+}
+
+func (m *mockCampaignRepo) GetUserGrants(ctx context.Context, userID string) ([]models.CampaignGrant, error) {
+	return m.grants, nil
+}
+
+func TestCampaignService_CreateCampaign(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(48 * time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		req     models.CreateCampaignRequest
+		wantErr error
+	}{
+		{"signup", models.CreateCampaignRequest{Name: "Welcome", Kind: models.CampaignKindSignup, Amount: 100}, nil},
+		{"promo with window", models.CreateCampaignRequest{Name: "Weekend", Kind: models.CampaignKindPromo, Amount: 10, EndsAt: &later}, nil},
+		{"repeating promo", models.CreateCampaignRequest{Name: "Daily", Kind: models.CampaignKindPromo, Amount: 1, EndsAt: &later, PerUserCap: 3, RepeatEverySeconds: 86400}, nil},
+		{"promo cap without interval", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindPromo, Amount: 10, PerUserCap: 3}, ErrInvalidCampaign},
+		{"promo interval without cap", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindPromo, Amount: 10, RepeatEverySeconds: 60}, ErrInvalidCampaign},
+		{"negative interval", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindPromo, Amount: 10, PerUserCap: 2, RepeatEverySeconds: -1}, ErrInvalidCampaign},
+		{"signup repeats", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindSignup, Amount: 10, RepeatEverySeconds: 60}, ErrInvalidCampaign},
+		{"unknown kind", models.CreateCampaignRequest{Name: "X", Kind: "birthday", Amount: 10}, ErrInvalidCampaign},
+		{"no amount", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindSignup}, ErrInvalidCampaign},
+		{"window ends before start", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindPromo, Amount: 10, EndsAt: &earlier}, ErrInvalidCampaign},
+		{"signup cap above one", models.CreateCampaignRequest{Name: "X", Kind: models.CampaignKindSignup, Amount: 10, PerUserCap: 2}, ErrInvalidCampaign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewCampaignService(&mockCampaignRepo{}, &mockOrderRepo{})
+			svc.now = func() time.Time { return now }
+
+			c, err := svc.CreateCampaign(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, now, c.StartsAt)
+			assert.GreaterOrEqual(t, c.PerUserCap, 1)
+		})
+	}
+}
+
+func TestCampaignService_GrantPromos(t *testing.T) {
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+
+	repo := &mockCampaignRepo{campaigns: []models.Campaign{
+		{ID: "weekend", Kind: models.CampaignKindPromo, Amount: 10, StartsAt: now.Add(-24 * time.Hour), PerUserCap: 1},
+		{ID: "expired", Kind: models.CampaignKindPromo, Amount: 50, StartsAt: now.Add(-48 * time.Hour), EndsAt: &ended, PerUserCap: 1},
+		{ID: "welcome", Kind: models.CampaignKindSignup, Amount: 100, StartsAt: now.Add(-48 * time.Hour), PerUserCap: 1},
+	}}
+	svc := NewCampaignService(repo, &mockOrderRepo{})
+	svc.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, svc.GrantPromos(context.Background(), "user-1"))
+	}
+
+	assert.Len(t, repo.grants, 1, "a promo pays a member once however often they log in")
+	assert.Equal(t, "weekend", repo.grants[0].CampaignID)
+	assert.Equal(t, 10.0, repo.grants[0].Amount)
+
+	assert.NoError(t, svc.GrantPromos(context.Background(), "user-2"))
+	assert.Len(t, repo.grants, 2, "each member is paid once")
+}
+
+func TestCampaignService_GrantPromos_Repeating(t *testing.T) {
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	repo := &mockCampaignRepo{campaigns: []models.Campaign{
+		{ID: "daily", Kind: models.CampaignKindPromo, Amount: 1, StartsAt: now.Add(-time.Hour), PerUserCap: 2, RepeatEverySeconds: 86400},
+	}}
+	svc := NewCampaignService(repo, &mockOrderRepo{})
+
+	logins := []struct {
+		at         time.Time
+		wantGrants int
+	}{
+		{now, 1},
+		{now.Add(time.Hour), 1},
+		{now.Add(24 * time.Hour), 2},
+		{now.Add(72 * time.Hour), 2},
+	}
+	for _, login := range logins {
+		svc.now = func() time.Time { return login.at }
+		assert.NoError(t, svc.GrantPromos(context.Background(), "user-1"))
+		assert.Len(t, repo.grants, login.wantGrants, "login at %s", login.at)
+	}
+}
+
+func TestCampaignService_GrantFirstOrder(t *testing.T) {
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	campaigns := []models.Campaign{
+		{ID: "first", Kind: models.CampaignKindFirstOrder, Amount: 25, StartsAt: now.Add(-time.Hour), PerUserCap: 1},
+	}
+
+	tests := []struct {
+		name      string
+		orders    map[string]models.Order
+		wantGrant bool
+	}{
+		{
+			name: "first processed order",
+			orders: map[string]models.Order{
+				"79927398713": {Number: "79927398713", UserID: "user-1", Status: models.OrderStatusProcessed},
+			},
+			wantGrant: true,
+		},
+		{
+			name: "member already had a processed order",
+			orders: map[string]models.Order{
+				"79927398713": {Number: "79927398713", UserID: "user-1", Status: models.OrderStatusProcessed},
+				"12345678903": {Number: "12345678903", UserID: "user-1", Status: models.OrderStatusProcessed},
+			},
+			wantGrant: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockCampaignRepo{campaigns: campaigns}
+			svc := NewCampaignService(repo, &mockOrderRepo{orders: tt.orders})
+			svc.now = func() time.Time { return now }
+
+			assert.NoError(t, svc.GrantFirstOrder(context.Background(), "user-1", "79927398713"))
+			assert.Equal(t, tt.wantGrant, len(repo.grants) == 1)
+			if tt.wantGrant {
+				assert.Equal(t, "79927398713", repo.grants[0].Reference)
+			}
+		})
+	}
+}
+
+func TestUserService_Register_GrantsSignupCampaign(t *testing.T) {
+	repo := &mockCampaignRepo{campaigns: []models.Campaign{
+		{ID: "welcome", Kind: models.CampaignKindSignup, Amount: 729.98, StartsAt: time.Now().Add(-time.Hour), PerUserCap: 1},
+	}}
+
+	users := NewUserService(&mockUserRepo{
+		CreateUserFunc: func(ctx context.Context, login, password string) (*models.User, error) {
+			return &models.User{ID: "user-1", Login: login}, nil
+		},
+	})
+	users.SetCampaigns(NewCampaignService(repo, &mockOrderRepo{}))
+
+	_, err := users.Register(context.Background(), "user", "pass", "")
+	assert.NoError(t, err)
+	assert.Len(t, repo.grants, 1)
+	assert.Equal(t, "user-1", repo.grants[0].UserID)
+	assert.Equal(t, 729.98, repo.grants[0].Amount)
+}
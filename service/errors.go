@@ -2,9 +2,130 @@ package service
 
 import "errors"
 
+// Kind classifies a domain error so transports can answer it without knowing
+// every individual sentinel.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindUpstream
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindUpstream:
+		return "upstream"
+	default:
+		return "internal"
+	}
+}
+
+// Error is a domain error of a given kind. The sentinels below are *Error
+// values, so errors.Is keeps working on them; a wrapped cause, such as the
+// driver error a repository translated, stays reachable through Unwrap.
+type Error struct {
+	Kind Kind
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Msg + ": " + e.Err.Error()
+	}
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind Kind, msg string) *Error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+// Wrap attaches kind and msg to err. It returns nil when err is nil.
+func Wrap(kind Kind, msg string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Msg: msg, Err: err}
+}
+
+// KindOf reports the kind of the outermost *Error in err's chain, or
+// KindInternal when there is none.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// Generic errors repositories return when a storage failure has no more
+// specific meaning.
+var (
+	ErrNotFound         = newError(KindNotFound, "not found")
+	ErrConflict         = newError(KindConflict, "conflict")
+	ErrInvalidReference = newError(KindValidation, "referenced record does not exist")
+	ErrSerialization    = newError(KindConflict, "concurrent update, retry the operation")
+	ErrUpstream         = newError(KindUpstream, "upstream service failed")
+)
+
 var (
-	ErrInvalidOrder         = errors.New("invalid order")
-	ErrInsufficientFunds    = errors.New("insufficient funds")
-	ErrAlreadyUploadedSelf  = errors.New("order already uploaded by user")
-	ErrAlreadyUploadedOther = errors.New("order uploaded by another user")
+	ErrInvalidOrder         = newError(KindValidation, "invalid order")
+	ErrInvalidSum           = newError(KindValidation, "sum must be positive")
+	ErrInsufficientFunds    = newError(KindConflict, "insufficient funds")
+	ErrAlreadyUploadedSelf  = newError(KindConflict, "order already uploaded by user")
+	ErrAlreadyUploadedOther = newError(KindConflict, "order uploaded by another user")
+	ErrLoginTaken           = newError(KindConflict, "login already exists")
+	ErrCredentialsRequired  = newError(KindValidation, "login and password required")
+	ErrInvalidCredentials   = newError(KindUnauthorized, "invalid login or password")
+	ErrHoldNotFound         = newError(KindNotFound, "hold not found")
+	ErrHoldNotActive        = newError(KindConflict, "hold is not active")
+	ErrMemberNotFound       = newError(KindNotFound, "member not found")
+	ErrInvalidMember        = newError(KindValidation, "either login or card must be set")
+	ErrMerchantNameRequired = newError(KindValidation, "merchant name required")
+	ErrInvalidMerchantToken = newError(KindUnauthorized, "invalid merchant token")
+	ErrMerchantNotFound     = newError(KindNotFound, "merchant not found")
+	ErrInvalidAPIKey        = newError(KindUnauthorized, "invalid api key")
+	ErrAPIKeyNameRequired   = newError(KindValidation, "api key name required")
+	ErrInvalidScope         = newError(KindValidation, "invalid api key scope")
+	ErrAPIKeyNotFound       = newError(KindNotFound, "api key not found")
+	ErrUserNotFound         = newError(KindNotFound, "user not found")
+	ErrUserBlocked          = newError(KindForbidden, "user is blocked")
+	ErrInvalidRole          = newError(KindValidation, "invalid role")
+	ErrInvalidAdjustment    = newError(KindValidation, "adjustment needs a non-zero amount and a reason")
+	ErrOrderNotFound        = newError(KindNotFound, "order not found")
+	ErrOrderFinal           = newError(KindConflict, "order is already processed")
+	ErrInvalidCampaign      = newError(KindValidation, "invalid campaign")
+	ErrCampaignNotFound     = newError(KindNotFound, "campaign not found")
+	ErrInvalidAccrualRule   = newError(KindValidation, "invalid accrual rule")
+	ErrAccrualRuleNotFound  = newError(KindNotFound, "accrual rule not found")
+	ErrInvalidReferralCode  = newError(KindValidation, "invalid referral code")
+	ErrSelfReferral         = newError(KindValidation, "members cannot refer themselves")
+	ErrInvalidVoucherBatch  = newError(KindValidation, "invalid voucher batch")
+	ErrVoucherNotFound      = newError(KindNotFound, "voucher not found")
+	ErrVoucherRedeemed      = newError(KindConflict, "voucher already redeemed")
+	ErrVoucherExpired       = newError(KindConflict, "voucher expired")
+	ErrInvalidAuditFilter   = newError(KindValidation, "invalid audit filter")
+	ErrInvalidWebhook       = newError(KindValidation, "invalid webhook")
+	ErrTooManyWebhooks      = newError(KindConflict, "too many webhooks")
+	ErrWebhookNotFound      = newError(KindNotFound, "webhook not found")
+	ErrDeliveryNotFound     = newError(KindNotFound, "webhook delivery not found")
 )
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+)
+
+const maxHoldTTL = 24 * time.Hour
+
+type HoldService struct {
+	repo       repository.HoldRepository
+	defaultTTL time.Duration
+	now        func() time.Time
+	logger     *slog.Logger
+}
+
+func NewHoldService(repo repository.HoldRepository, defaultTTL time.Duration) *HoldService {
+	return &HoldService{
+		repo:       repo,
+		defaultTTL: defaultTTL,
+		now:        time.Now,
+		logger:     slog.Default(),
+	}
+}
+
+func (s *HoldService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// Authorize reserves sum points against order. The points stop counting
+// towards the available balance until the hold is captured, voided or expires.
+func (s *HoldService) Authorize(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error) {
+	if order == "" || !orderRegexp.MatchString(order) {
+		return nil, ErrInvalidOrder
+	}
+	if sum <= 0 {
+		return nil, ErrInvalidSum
+	}
+
+	if ttl <= 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl > maxHoldTTL {
+		ttl = maxHoldTTL
+	}
+
+	now := s.now()
+	hold := models.PointHold{
+		OrderNumber: order,
+		UserID:      userID,
+		Sum:         sum,
+		Status:      models.HoldStatusAuthorized,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	if err := s.repo.AuthorizeHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (s *HoldService) Capture(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	return s.repo.CaptureHold(ctx, userID, order)
+}
+
+func (s *HoldService) Void(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	return s.repo.VoidHold(ctx, userID, order)
+}
+
+func (s *HoldService) GetHeld(ctx context.Context, userID string) (float64, error) {
+	return s.repo.GetHeldPoints(ctx, userID)
+}
+
+func (s *HoldService) GetHolds(ctx context.Context, userID string) ([]models.PointHold, error) {
+	return s.repo.GetUserHolds(ctx, userID)
+}
+
+func (s *HoldService) ExpireHolds(ctx context.Context) (int, error) {
+	return s.repo.ExpireHolds(ctx, s.now())
+}
+
+// RunExpirySweeper releases expired holds every interval until ctx is done.
+func (s *HoldService) RunExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ExpireHolds(ctx)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "hold sweeper failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				s.logger.InfoContext(ctx, "hold sweeper expired holds", "count", n)
+			}
+		}
+	}
+}
+
+type HoldServiceType interface {
+	Authorize(ctx context.Context, userID, order string, sum float64, ttl time.Duration) (*models.PointHold, error)
+	Capture(ctx context.Context, userID, order string) (*models.PointHold, error)
+	Void(ctx context.Context, userID, order string) (*models.PointHold, error)
+	GetHeld(ctx context.Context, userID string) (float64, error)
+	GetHolds(ctx context.Context, userID string) ([]models.PointHold, error)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHoldRepo struct {
+	AuthorizeHoldFunc func(ctx context.Context, hold models.PointHold) error
+	ExpireHoldsFunc   func(ctx context.Context, now time.Time) (int, error)
+}
+
+func (m *mockHoldRepo) AuthorizeHold(ctx context.Context, hold models.PointHold) error {
+	if m.AuthorizeHoldFunc != nil {
+		return m.AuthorizeHoldFunc(ctx, hold)
+	}
+	return nil
+}
+
+func (m *mockHoldRepo) CaptureHold(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	return &models.PointHold{OrderNumber: order, UserID: userID, Status: models.HoldStatusCaptured}, nil
+}
+
+func (m *mockHoldRepo) VoidHold(ctx context.Context, userID, order string) (*models.PointHold, error) {
+	return &models.PointHold{OrderNumber: order, UserID: userID, Status: models.HoldStatusVoided}, nil
+}
+
+func (m *mockHoldRepo) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	if m.ExpireHoldsFunc != nil {
+		return m.ExpireHoldsFunc(ctx, now)
+	}
+	return 0, nil
+}
+
+func (m *mockHoldRepo) GetHeldPoints(ctx context.Context, userID string) (float64, error) {
+	return 0, nil
+}
+
+func (m *mockHoldRepo) GetUserHolds(ctx context.Context, userID string) ([]models.PointHold, error) {
+	return nil, nil
+}
+
+func TestHoldService_Authorize(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		order      string
+		sum        float64
+		ttl        time.Duration
+		repoErr    error
+		wantErr    error
+		wantExpiry time.Time
+	}{
+		{"default ttl", "12345678903", 100, 0, nil, nil, now.Add(15 * time.Minute)},
+		{"custom ttl", "12345678903", 100, time.Hour, nil, nil, now.Add(time.Hour)},
+		{"ttl capped", "12345678903", 100, 48 * time.Hour, nil, nil, now.Add(maxHoldTTL)},
+		{"empty order", "", 100, 0, nil, ErrInvalidOrder, time.Time{}},
+		{"non numeric order", "abc", 100, 0, nil, ErrInvalidOrder, time.Time{}},
+		{"zero sum", "12345678903", 0, 0, nil, ErrInvalidSum, time.Time{}},
+		{"negative sum", "12345678903", -5, 0, nil, ErrInvalidSum, time.Time{}},
+		{"insufficient funds", "12345678903", 100, 0, ErrInsufficientFunds, ErrInsufficientFunds, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored models.PointHold
+			repo := &mockHoldRepo{
+				AuthorizeHoldFunc: func(ctx context.Context, hold models.PointHold) error {
+					stored = hold
+					return tt.repoErr
+				},
+			}
+			svc := NewHoldService(repo, 15*time.Minute)
+			svc.now = func() time.Time { return now }
+
+			got, err := svc.Authorize(context.Background(), "user-1", tt.order, tt.sum, tt.ttl)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.HoldStatusAuthorized, got.Status)
+			assert.Equal(t, tt.wantExpiry, got.ExpiresAt)
+			assert.Equal(t, stored, *got)
+		})
+	}
+}
+
+func TestHoldService_ExpireHolds(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var passed time.Time
+	repo := &mockHoldRepo{
+		ExpireHoldsFunc: func(ctx context.Context, at time.Time) (int, error) {
+			passed = at
+			return 3, nil
+		},
+	}
+	svc := NewHoldService(repo, time.Minute)
+	svc.now = func() time.Time { return now }
+
+	n, err := svc.ExpireHolds(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, now, passed)
+}
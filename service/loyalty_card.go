@@ -0,0 +1,46 @@
+package service
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+const loyaltyCardPrefix = "7"
+
+// NewLoyaltyCardNumber returns a random 16-digit card number whose last digit
+// is a Luhn check digit, so cards pass the same validation as order numbers.
+func NewLoyaltyCardNumber() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1e14))
+	if err != nil {
+		return "", err
+	}
+
+	payload := loyaltyCardPrefix + leftPad(n.String(), 14)
+	return payload + luhnCheckDigit(payload), nil
+}
+
+func luhnCheckDigit(payload string) string {
+	sum := 0
+	double := true
+
+	for i := len(payload) - 1; i >= 0; i-- {
+		digit := int(payload[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return string(rune('0' + (10-sum%10)%10))
+}
+
+func leftPad(s string, width int) string {
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+)
+
+type MerchantService struct {
+	repo   repository.MerchantRepository
+	orders OrderService
+}
+
+func NewMerchantService(repo repository.MerchantRepository, orders OrderService) *MerchantService {
+	return &MerchantService{repo: repo, orders: orders}
+}
+
+// CreateMerchant registers a store and returns its API token. Only the token's
+// hash is stored, so the plain value cannot be recovered later.
+func (s *MerchantService) CreateMerchant(ctx context.Context, name string) (*models.Merchant, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrMerchantNameRequired
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	merchant := models.Merchant{
+		ID:        uuid.New().String(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateMerchant(ctx, merchant, HashToken(token)); err != nil {
+		return nil, "", err
+	}
+
+	return &merchant, token, nil
+}
+
+func (s *MerchantService) AuthenticateMerchant(ctx context.Context, token string) (*models.Merchant, error) {
+	if token == "" {
+		return nil, ErrInvalidMerchantToken
+	}
+	return s.repo.GetMerchantByTokenHash(ctx, HashToken(token))
+}
+
+// RegisterPurchase links a store purchase to a member and uploads the order on
+// their behalf. Re-sending a purchase for the same member reports
+// ErrAlreadyUploadedSelf so that stores can safely retry.
+func (s *MerchantService) RegisterPurchase(ctx context.Context, merchantID string, req models.MerchantPurchaseRequest) error {
+	login := strings.TrimSpace(req.Login)
+	card := strings.TrimSpace(req.Card)
+	if (login == "") == (card == "") {
+		return ErrInvalidMember
+	}
+	if req.Amount < 0 {
+		return ErrInvalidOrder
+	}
+
+	member, err := s.repo.FindMember(ctx, login, card)
+	if err != nil {
+		return err
+	}
+
+	orderNumber := strings.TrimSpace(req.Order)
+	uploadErr := s.orders.UploadOrder(ctx, member.ID, orderNumber)
+	if uploadErr != nil && !errors.Is(uploadErr, ErrAlreadyUploadedSelf) {
+		return uploadErr
+	}
+
+	// SavePurchase is idempotent, so a retry after a failed save still
+	// records which store the order came from.
+	err = s.repo.SavePurchase(ctx, models.MerchantPurchase{
+		OrderNumber: orderNumber,
+		MerchantID:  merchantID,
+		UserID:      member.ID,
+		Amount:      req.Amount,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return uploadErr
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type MerchantServiceType interface {
+	AuthenticateMerchant(ctx context.Context, token string) (*models.Merchant, error)
+	RegisterPurchase(ctx context.Context, merchantID string, req models.MerchantPurchaseRequest) error
+}
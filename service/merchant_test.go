@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMerchantRepo struct {
+	FindMemberFunc func(ctx context.Context, login, card string) (*models.User, error)
+	saved          []models.MerchantPurchase
+}
+
+func (m *mockMerchantRepo) CreateMerchant(ctx context.Context, merchant models.Merchant, tokenHash string) error {
+	return nil
+}
+
+func (m *mockMerchantRepo) GetMerchantByTokenHash(ctx context.Context, tokenHash string) (*models.Merchant, error) {
+	return nil, ErrInvalidMerchantToken
+}
+
+func (m *mockMerchantRepo) FindMember(ctx context.Context, login, card string) (*models.User, error) {
+	return m.FindMemberFunc(ctx, login, card)
+}
+
+func (m *mockMerchantRepo) SavePurchase(ctx context.Context, purchase models.MerchantPurchase) error {
+	m.saved = append(m.saved, purchase)
+	return nil
+}
+
+type mockOrderService struct {
+	UploadOrderFunc func(ctx context.Context, userID, orderNumber string) error
+}
+
+func (m *mockOrderService) UploadOrder(ctx context.Context, userID, orderNumber string) error {
+	return m.UploadOrderFunc(ctx, userID, orderNumber)
+}
+
+func (m *mockOrderService) GetOrders(ctx context.Context, userID string) ([]models.Order, error) {
+	return nil, nil
+}
+
+func TestMerchantService_RegisterPurchase(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       models.MerchantPurchaseRequest
+		memberErr error
+		uploadErr error
+		wantErr   error
+		wantSaved bool
+	}{
+		{"by login", models.MerchantPurchaseRequest{Order: "79927398713", Login: "alice"}, nil, nil, nil, true},
+		{"by card", models.MerchantPurchaseRequest{Order: "79927398713", Card: "7000000000000006"}, nil, nil, nil, true},
+		{"no identifier", models.MerchantPurchaseRequest{Order: "79927398713"}, nil, nil, ErrInvalidMember, false},
+		{"both identifiers", models.MerchantPurchaseRequest{Order: "79927398713", Login: "alice", Card: "7000000000000006"}, nil, nil, ErrInvalidMember, false},
+		{"member not found", models.MerchantPurchaseRequest{Order: "79927398713", Login: "bob"}, ErrMemberNotFound, nil, ErrMemberNotFound, false},
+		{"invalid order", models.MerchantPurchaseRequest{Order: "1", Login: "alice"}, nil, ErrInvalidOrder, ErrInvalidOrder, false},
+		{"retry for same member", models.MerchantPurchaseRequest{Order: "79927398713", Login: "alice"}, nil, ErrAlreadyUploadedSelf, ErrAlreadyUploadedSelf, true},
+		{"other member owns order", models.MerchantPurchaseRequest{Order: "79927398713", Login: "alice"}, nil, ErrAlreadyUploadedOther, ErrAlreadyUploadedOther, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockMerchantRepo{
+				FindMemberFunc: func(ctx context.Context, login, card string) (*models.User, error) {
+					if tt.memberErr != nil {
+						return nil, tt.memberErr
+					}
+					return &models.User{ID: "user-1", Login: login, LoyaltyCard: card}, nil
+				},
+			}
+			orders := &mockOrderService{
+				UploadOrderFunc: func(ctx context.Context, userID, orderNumber string) error {
+					assert.Equal(t, "user-1", userID)
+					return tt.uploadErr
+				},
+			}
+
+			svc := NewMerchantService(repo, orders)
+			err := svc.RegisterPurchase(context.Background(), "merchant-1", tt.req)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v, want %v", err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantSaved, len(repo.saved) == 1)
+		})
+	}
+}
+
+func TestNewLoyaltyCardNumber(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		card, err := NewLoyaltyCardNumber()
+		assert.NoError(t, err)
+		assert.Len(t, card, 16)
+		assert.True(t, CheckLuhn(card), "card %s fails Luhn", card)
+	}
+}
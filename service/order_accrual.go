@@ -3,33 +3,46 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Guldana11/gophermart/models"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 var (
-	ErrTooManyReq = errors.New("too many requests")
+	ErrTooManyReq = newError(KindUpstream, "too many requests")
 )
 
 type LoyaltyService interface {
 	GetOrderAccrual(ctx context.Context, orderNumber string) (*models.OrderAccrualResponse, error)
 }
 
+// AccrualObserver records calls to the accrual system. outcome is the status
+// the accrual system answered with, "5xx" for server errors, or "error" when
+// no answer arrived.
+type AccrualObserver interface {
+	ObserveAccrualCall(outcome string, elapsed time.Duration)
+}
+
 type loyaltyService struct {
-	baseURL string
-	client  *http.Client
+	baseURL  string
+	client   *http.Client
+	observer AccrualObserver
 }
 
-func NewLoyaltyService(baseURL string) LoyaltyService {
+// NewLoyaltyService returns a client for the accrual system. observer may be
+// nil.
+func NewLoyaltyService(baseURL string, observer AccrualObserver) LoyaltyService {
 	return &loyaltyService{
 		baseURL: baseURL,
 		client: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   5 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
+		observer: observer,
 	}
 }
 
@@ -48,9 +61,11 @@ func (s *loyaltyService) GetOrderAccrual(
 		return nil, err
 	}
 
+	start := time.Now()
 	resp, err := s.client.Do(req)
+	s.observe(resp, time.Since(start))
 	if err != nil {
-		return nil, err
+		return nil, Wrap(KindUpstream, "accrual request failed", err)
 	}
 	defer resp.Body.Close()
 
@@ -59,7 +74,7 @@ func (s *loyaltyService) GetOrderAccrual(
 	case http.StatusOK:
 		var res models.OrderAccrualResponse
 		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-			return nil, err
+			return nil, Wrap(KindUpstream, "malformed accrual response", err)
 		}
 		return &res, nil
 
@@ -79,6 +94,43 @@ func (s *loyaltyService) GetOrderAccrual(
 		return nil, ErrTooManyReq
 
 	default:
-		return nil, errors.New("unexpected accrual service response")
+		return nil, fmt.Errorf("%w: accrual system answered %d", ErrUpstream, resp.StatusCode)
+	}
+}
+
+func (s *loyaltyService) observe(resp *http.Response, elapsed time.Duration) {
+	if s.observer == nil {
+		return
+	}
+
+	outcome := "error"
+	switch {
+	case resp == nil:
+	case resp.StatusCode >= 500:
+		outcome = "5xx"
+	default:
+		outcome = strconv.Itoa(resp.StatusCode)
+	}
+	s.observer.ObserveAccrualCall(outcome, elapsed)
+}
+
+// PingAccrualSystem reports whether the accrual system at baseURL answers at
+// all. Any answer short of a server error counts, since there is no health
+// route to ask.
+func PingAccrualSystem(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Wrap(KindUpstream, "accrual system unreachable", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: accrual system answered %d", ErrUpstream, resp.StatusCode)
 	}
+	return nil
 }
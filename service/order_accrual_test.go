@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recordingAccrualObserver struct {
+	outcomes []string
+}
+
+func (r *recordingAccrualObserver) ObserveAccrualCall(outcome string, elapsed time.Duration) {
+	r.outcomes = append(r.outcomes, outcome)
+}
+
+func TestLoyaltyService_ObservesOutcome(t *testing.T) {
+	tests := []struct {
+		status  int
+		outcome string
+	}{
+		{http.StatusOK, "200"},
+		{http.StatusNoContent, "204"},
+		{http.StatusNotFound, "404"},
+		{http.StatusTooManyRequests, "429"},
+		{http.StatusBadGateway, "5xx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					_, _ = w.Write([]byte(`{"order":"79927398713","status":"PROCESSED","accrual":10}`))
+				}
+			}))
+			defer srv.Close()
+
+			observer := &recordingAccrualObserver{}
+			_, _ = NewLoyaltyService(srv.URL, observer).GetOrderAccrual(context.Background(), "79927398713")
+			assert.Equal(t, []string{tt.outcome}, observer.outcomes)
+		})
+	}
+
+	t.Run("no answer", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		observer := &recordingAccrualObserver{}
+		_, err := NewLoyaltyService(srv.URL, observer).GetOrderAccrual(context.Background(), "79927398713")
+		assert.Equal(t, KindUpstream, KindOf(err))
+		assert.Equal(t, []string{"error"}, observer.outcomes)
+	})
+}
+
+func TestLoyaltyService_PropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "GET /api/user/orders")
+	_, err := NewLoyaltyService(srv.URL, nil).GetOrderAccrual(ctx, "79927398713")
+	parent.End()
+	assert.NoError(t, err)
+
+	assert.Contains(t, traceparent, parent.SpanContext().TraceID().String())
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	}
+}
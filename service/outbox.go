@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+)
+
+const (
+	outboxBatchSize  = 100
+	outboxMaxBackoff = 10 * time.Minute
+	// outboxLease is how long claimed events are left to the relay that
+	// claimed them. It covers publishing a whole batch.
+	outboxLease = 5 * time.Minute
+)
+
+// EventPublisher delivers an outbox event to subscribers. An error leaves the
+// event in the outbox to be retried.
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.Event) error
+}
+
+// EventPublishers publishes every event to each publisher in turn. When one
+// fails the event is retried on all of them, so each must tolerate seeing an
+// event twice.
+type EventPublishers []EventPublisher
+
+func (p EventPublishers) Publish(ctx context.Context, event models.Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutboxRelay publishes the events recorded in the outbox. Events of one
+// member are published in the order they were recorded: when one fails, the
+// member's later events wait until it has been delivered.
+type OutboxRelay struct {
+	repo      repository.OutboxRepository
+	tx        repository.TxManager
+	publisher EventPublisher
+	retention time.Duration
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+// NewOutboxRelay keeps published events for retention; zero keeps them
+// forever.
+func NewOutboxRelay(repo repository.OutboxRepository, tx repository.TxManager, publisher EventPublisher, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		repo:      repo,
+		tx:        tx,
+		publisher: publisher,
+		retention: retention,
+		now:       time.Now,
+		logger:    slog.Default(),
+	}
+}
+
+func (r *OutboxRelay) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+// RelayOnce publishes one batch of due events and returns how many were
+// delivered. It publishes nothing while another relay is claiming events.
+//
+// The batch is claimed, and leased for outboxLease, in a short unit of work.
+// Events are published with no transaction open and each outcome is recorded
+// on its own, so a slow subscriber holds no locks and a crash between publish
+// and record only delivers the event again once the lease runs out.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	now := r.now()
+
+	var events []models.Event
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		claimed, ok, err := r.repo.ClaimEvents(ctx, now, now.Add(outboxLease), outboxBatchSize)
+		if err != nil || !ok {
+			events = nil
+			return err
+		}
+		events = claimed
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var published int
+	blocked := make(map[string]bool)
+	var released []int64
+	for i, event := range events {
+		// Stop well before the lease runs out and another relay may claim
+		// the rest of the batch.
+		if r.now().Sub(now) > outboxLease/2 {
+			return published, r.release(ctx, nil, released, events[i:], now)
+		}
+		if blocked[event.UserID] {
+			released = append(released, event.Seq)
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			blocked[event.UserID] = true
+			r.logger.WarnContext(ctx, "event not published",
+				"event_id", event.ID, "type", event.Type, "attempts", event.Attempts+1, "error", err)
+			if err := r.repo.MarkFailed(ctx, event.Seq, err.Error(), r.now().Add(outboxBackoff(event.Attempts))); err != nil {
+				return published, r.release(ctx, err, released, events[i+1:], now)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkPublished(ctx, event.Seq, r.now()); err != nil {
+			return published, r.release(ctx, err, released, events[i+1:], now)
+		}
+		published++
+	}
+	return published, r.release(ctx, nil, released, nil, now)
+}
+
+// release hands back events of the batch that were skipped or not reached,
+// so they need not wait for their lease to run out. Events behind a failed
+// one stay held back by it.
+func (r *OutboxRelay) release(ctx context.Context, err error, skipped []int64, rest []models.Event, at time.Time) error {
+	for _, event := range rest {
+		skipped = append(skipped, event.Seq)
+	}
+	if len(skipped) == 0 {
+		return err
+	}
+	return errors.Join(err, r.repo.ReleaseEvents(ctx, skipped, at))
+}
+
+// outboxBackoff doubles the wait after every failed attempt, up to
+// outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	if attempts >= 10 {
+		return outboxMaxBackoff
+	}
+	return min(time.Second<<attempts, outboxMaxBackoff)
+}
+
+// PurgePublished deletes events published longer ago than the retention
+// period.
+func (r *OutboxRelay) PurgePublished(ctx context.Context) (int64, error) {
+	if r.retention <= 0 {
+		return 0, nil
+	}
+	return r.repo.PurgePublished(ctx, r.now().Add(-r.retention))
+}
+
+// Run relays events every interval, and purges published ones hourly, until
+// ctx is done.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.RelayOnce(ctx); err != nil {
+				r.logger.ErrorContext(ctx, "outbox relay failed", "error", err)
+			}
+		case <-purge.C:
+			n, err := r.PurgePublished(ctx)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "outbox retention failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				r.logger.InfoContext(ctx, "outbox retention purged events", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOutbox struct {
+	events     []models.Event
+	locked     bool
+	leaseUntil time.Time
+	published  []int64
+	failed     map[int64]time.Time
+	released   []int64
+	inUnit     []bool
+}
+
+func (o *fakeOutbox) ClaimEvents(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.Event, bool, error) {
+	if !inUnit(ctx) {
+		return nil, false, errors.New("not in a unit of work")
+	}
+	if o.locked {
+		return nil, false, nil
+	}
+	o.leaseUntil = leaseUntil
+	return o.events, true, nil
+}
+
+func (o *fakeOutbox) MarkPublished(ctx context.Context, seq int64, at time.Time) error {
+	o.inUnit = append(o.inUnit, inUnit(ctx))
+	o.published = append(o.published, seq)
+	return nil
+}
+
+func (o *fakeOutbox) MarkFailed(ctx context.Context, seq int64, reason string, retryAt time.Time) error {
+	o.inUnit = append(o.inUnit, inUnit(ctx))
+	if o.failed == nil {
+		o.failed = make(map[int64]time.Time)
+	}
+	o.failed[seq] = retryAt
+	return nil
+}
+
+func (o *fakeOutbox) ReleaseEvents(ctx context.Context, seqs []int64, at time.Time) error {
+	o.released = append(o.released, seqs...)
+	return nil
+}
+
+func (o *fakeOutbox) PurgePublished(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+type publisherFunc func(ctx context.Context, event models.Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, event models.Event) error { return f(ctx, event) }
+
+func TestOutboxRelay_RelayOnce(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeOutbox{events: []models.Event{
+		{Seq: 1, ID: "a", UserID: "u1", Attempts: 2},
+		{Seq: 2, ID: "b", UserID: "u2"},
+		{Seq: 3, ID: "c", UserID: "u1"},
+		{Seq: 4, ID: "d", UserID: "u2"},
+	}}
+	var delivered []string
+	publisher := publisherFunc(func(ctx context.Context, event models.Event) error {
+		if inUnit(ctx) {
+			t.Errorf("event %s published inside a unit of work", event.ID)
+		}
+		if event.ID == "a" {
+			return errors.New("receiver down")
+		}
+		delivered = append(delivered, event.ID)
+		return nil
+	})
+
+	relay := NewOutboxRelay(repo, &fakeTxManager{}, publisher, 0)
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	// u1's later event waits behind the failed one; u2 is unaffected.
+	assert.Equal(t, []string{"b", "d"}, delivered)
+	assert.Equal(t, []int64{2, 4}, repo.published)
+	assert.Equal(t, map[int64]time.Time{1: now.Add(4 * time.Second)}, repo.failed)
+	assert.Equal(t, now.Add(outboxLease), repo.leaseUntil)
+	// Each outcome is recorded on its own, outside the claim.
+	assert.Equal(t, []bool{false, false, false}, repo.inUnit)
+	assert.Equal(t, []int64{3}, repo.released)
+}
+
+func TestOutboxRelay_RelayOnce_LeaseRunningOut(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := now
+	repo := &fakeOutbox{events: []models.Event{
+		{Seq: 1, ID: "a", UserID: "u1"},
+		{Seq: 2, ID: "b", UserID: "u2"},
+		{Seq: 3, ID: "c", UserID: "u3"},
+	}}
+	publisher := publisherFunc(func(ctx context.Context, event models.Event) error {
+		clock = clock.Add(outboxLease / 3)
+		return nil
+	})
+
+	relay := NewOutboxRelay(repo, &fakeTxManager{}, publisher, 0)
+	relay.now = func() time.Time { return clock }
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{1, 2}, repo.published)
+	assert.Equal(t, []int64{3}, repo.released)
+}
+
+func TestOutboxRelay_RelayOnce_Locked(t *testing.T) {
+	repo := &fakeOutbox{events: []models.Event{{Seq: 1, UserID: "u1"}}, locked: true}
+	publisher := publisherFunc(func(ctx context.Context, event models.Event) error {
+		t.Error("published while another relay holds the outbox")
+		return nil
+	})
+
+	n, err := NewOutboxRelay(repo, &fakeTxManager{}, publisher, 0).RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, outboxBackoff(0))
+	assert.Equal(t, 8*time.Second, outboxBackoff(3))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(10))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(100))
+}
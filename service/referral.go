@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+)
+
+// referralAlphabet leaves out characters that are easy to confuse when a code
+// is read aloud or copied by hand (0/O, 1/I/L).
+const referralAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const referralCodeLength = 8
+
+// NewReferralCode returns a random code members share to refer others.
+func NewReferralCode() (string, error) {
+	return randomCode(referralCodeLength)
+}
+
+// randomCode draws length characters from referralAlphabet. Bytes that would
+// bias the draw towards the first letters are rejected.
+func randomCode(length int) (string, error) {
+	limit := 256 - 256%len(referralAlphabet)
+	code := make([]byte, 0, length)
+	buf := make([]byte, length)
+
+	for len(code) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit || len(code) == length {
+				continue
+			}
+			code = append(code, referralAlphabet[int(b)%len(referralAlphabet)])
+		}
+	}
+	return string(code), nil
+}
+
+type ReferralConfig struct {
+	ReferrerReward float64
+	RefereeReward  float64
+	// MaxRewards caps how many referrals a single member is rewarded for.
+	MaxRewards int
+}
+
+// ReferralConfigFromEnv reads REFERRAL_REFERRER_REWARD, REFERRAL_REFEREE_REWARD
+// and REFERRAL_MAX_REWARDS, falling back to 100, 50 and 20. Both binaries use
+// it so the server and gophermartctl pay out the same rewards.
+func ReferralConfigFromEnv(getenv func(string) string) (ReferralConfig, error) {
+	cfg := ReferralConfig{ReferrerReward: 100, RefereeReward: 50, MaxRewards: 20}
+
+	for name, dst := range map[string]*float64{
+		"REFERRAL_REFERRER_REWARD": &cfg.ReferrerReward,
+		"REFERRAL_REFEREE_REWARD":  &cfg.RefereeReward,
+	} {
+		if v := getenv(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = f
+		}
+	}
+
+	if v := getenv("REFERRAL_MAX_REWARDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid REFERRAL_MAX_REWARDS: %q", v)
+		}
+		cfg.MaxRewards = n
+	}
+	return cfg, nil
+}
+
+// ReferralLinker is what registration needs from the referral program.
+type ReferralLinker interface {
+	ResolveCode(ctx context.Context, code string) (string, error)
+	Link(ctx context.Context, referrerID, refereeID string) error
+}
+
+type ReferralService struct {
+	repo repository.ReferralRepository
+	cfg  ReferralConfig
+}
+
+func NewReferralService(repo repository.ReferralRepository, cfg ReferralConfig) *ReferralService {
+	return &ReferralService{repo: repo, cfg: cfg}
+}
+
+// ResolveCode returns the ID of the member owning code. Codes of blocked
+// members are rejected like unknown ones.
+func (s *ReferralService) ResolveCode(ctx context.Context, code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", ErrInvalidReferralCode
+	}
+
+	referrer, err := s.repo.GetUserByReferralCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return "", ErrInvalidReferralCode
+		}
+		return "", err
+	}
+	if referrer.BlockedAt != nil {
+		return "", ErrInvalidReferralCode
+	}
+	return referrer.ID, nil
+}
+
+func (s *ReferralService) Link(ctx context.Context, referrerID, refereeID string) error {
+	if referrerID == refereeID {
+		return ErrSelfReferral
+	}
+	return s.repo.CreateReferral(ctx, models.Referral{
+		ReferrerID: referrerID,
+		RefereeID:  refereeID,
+		Status:     models.ReferralStatusPending,
+	})
+}
+
+// RewardFirstOrder settles the member's pending referral when one of their
+// orders is processed. Later orders find nothing pending and credit nothing.
+func (s *ReferralService) RewardFirstOrder(ctx context.Context, userID, orderNumber string) error {
+	_, err := s.repo.RewardReferral(ctx, userID, orderNumber, s.cfg.ReferrerReward, s.cfg.RefereeReward, s.cfg.MaxRewards)
+	return err
+}
+
+func (s *ReferralService) ListReferrals(ctx context.Context, userID string) ([]models.Referral, error) {
+	return s.repo.ListReferrals(ctx, userID)
+}
+
+type ReferralServiceType interface {
+	ListReferrals(ctx context.Context, userID string) ([]models.Referral, error)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReferralRepo struct {
+	users     map[string]models.User
+	referrals []models.Referral
+	rewarded  []string
+}
+
+func (m *mockReferralRepo) GetUserByReferralCode(ctx context.Context, code string) (*models.User, error) {
+	u, ok := m.users[code]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &u, nil
+}
+
+func (m *mockReferralRepo) CreateReferral(ctx context.Context, referral models.Referral) error {
+	m.referrals = append(m.referrals, referral)
+	return nil
+}
+
+func (m *mockReferralRepo) RewardReferral(ctx context.Context, refereeID, orderNumber string, referrerReward, refereeReward float64, maxRewards int) (*models.Referral, error) {
+	m.rewarded = append(m.rewarded, refereeID)
+	return nil, nil
+}
+
+func (m *mockReferralRepo) ListReferrals(ctx context.Context, referrerID string) ([]models.Referral, error) {
+	return m.referrals, nil
+}
+
+func TestUserService_Register_Referral(t *testing.T) {
+	blockedAt := time.Now()
+	referrals := &mockReferralRepo{users: map[string]models.User{
+		"FRIEND23": {ID: "referrer-1"},
+		"BLOCKED9": {ID: "referrer-2", BlockedAt: &blockedAt},
+	}}
+
+	tests := []struct {
+		name        string
+		code        string
+		wantErr     error
+		wantCreated bool
+		wantLinked  string
+	}{
+		{"no code", "", nil, true, ""},
+		{"valid code", "friend23", nil, true, "referrer-1"},
+		{"unknown code", "NOPE1234", ErrInvalidReferralCode, false, ""},
+		{"blocked referrer", "BLOCKED9", ErrInvalidReferralCode, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			referrals.referrals = nil
+			created := false
+
+			svc := NewUserService(&mockUserRepo{
+				CreateUserFunc: func(ctx context.Context, login, password string) (*models.User, error) {
+					created = true
+					return &models.User{ID: "referee-1", Login: login}, nil
+				},
+			})
+			svc.SetReferrals(NewReferralService(referrals, ReferralConfig{}))
+
+			_, err := svc.Register(context.Background(), "user", "pass", tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCreated, created, "an invalid code must fail before the account exists")
+
+			if tt.wantLinked == "" {
+				assert.Empty(t, referrals.referrals)
+				return
+			}
+			assert.Len(t, referrals.referrals, 1)
+			assert.Equal(t, tt.wantLinked, referrals.referrals[0].ReferrerID)
+			assert.Equal(t, "referee-1", referrals.referrals[0].RefereeID)
+			assert.Equal(t, models.ReferralStatusPending, referrals.referrals[0].Status)
+		})
+	}
+}
+
+func TestReferralService_Link_SelfReferral(t *testing.T) {
+	svc := NewReferralService(&mockReferralRepo{}, ReferralConfig{})
+
+	err := svc.Link(context.Background(), "user-1", "user-1")
+	assert.ErrorIs(t, err, ErrSelfReferral)
+}
+
+func TestReferralConfigFromEnv(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+
+	cfg, err := ReferralConfigFromEnv(env(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, ReferralConfig{ReferrerReward: 100, RefereeReward: 50, MaxRewards: 20}, cfg)
+
+	cfg, err = ReferralConfigFromEnv(env(map[string]string{
+		"REFERRAL_REFERRER_REWARD": "250.5",
+		"REFERRAL_REFEREE_REWARD":  "0",
+		"REFERRAL_MAX_REWARDS":     "3",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, ReferralConfig{ReferrerReward: 250.5, RefereeReward: 0, MaxRewards: 3}, cfg)
+
+	_, err = ReferralConfigFromEnv(env(map[string]string{"REFERRAL_MAX_REWARDS": "-1"}))
+	assert.Error(t, err)
+}
+
+func TestAccrualSyncService_Rescore_RewardsReferral(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      string
+		after       string
+		wantRewards int
+	}{
+		{"order becomes processed", models.OrderStatusNew, "PROCESSED", 1},
+		{"order still processing", models.OrderStatusNew, "PROCESSING", 0},
+		{"order was already processed", models.OrderStatusProcessed, "PROCESSED", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &mockOrderRepo{orders: map[string]models.Order{
+				"79927398713": {Number: "79927398713", UserID: "referee-1", Status: tt.before},
+			}}
+			referrals := &mockReferralRepo{}
+
+			svc := NewAccrualSyncService(orders, &mockLoyaltyService{resp: &models.OrderAccrualResponse{Status: tt.after, Accrual: 100}})
+			svc.SetReferrals(NewReferralService(referrals, ReferralConfig{ReferrerReward: 100, RefereeReward: 50, MaxRewards: 1}))
+
+			_, err := svc.Rescore(context.Background(), "79927398713", true)
+			assert.NoError(t, err)
+			assert.Len(t, referrals.rewarded, tt.wantRewards)
+		})
+	}
+}
@@ -7,6 +7,6 @@ import (
 )
 
 type UserServiceInterface interface {
-	Register(ctx context.Context, login, password string) (*models.User, error)
+	Register(ctx context.Context, login, password, referralCode string) (*models.User, error)
 	Login(ctx context.Context, login, password string) (*models.User, error)
 }
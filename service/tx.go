@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Guldana11/gophermart/repository"
+)
+
+// inTx runs fn as one unit of work when a transaction manager is configured,
+// and directly otherwise.
+func inTx(ctx context.Context, tx repository.TxManager, fn func(ctx context.Context) error) error {
+	if tx == nil {
+		return fn(ctx)
+	}
+	return tx.WithinTx(ctx, fn)
+}
+
+// sideEffect reports a failed grant or reward that follows a write. Inside a
+// unit of work the failure undoes the write, so both commit together or not at
+// all; without one the write is already committed and the failure is only
+// logged.
+func sideEffect(ctx context.Context, tx repository.TxManager, logger *slog.Logger, msg string, err error, args ...any) error {
+	if tx != nil && err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	logFailure(ctx, logger, msg, err, args...)
+	return nil
+}
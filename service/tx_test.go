@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type inUnitKey struct{}
+
+// fakeTxManager runs fn attempts times, as a retried transaction would, and
+// marks the context so repositories can tell they are inside the unit.
+type fakeTxManager struct {
+	attempts int
+	runs     int
+}
+
+func (m *fakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for i := 0; i < max(m.attempts, 1); i++ {
+		m.runs++
+		err = fn(context.WithValue(ctx, inUnitKey{}, true))
+	}
+	return err
+}
+
+func inUnit(ctx context.Context) bool {
+	v, _ := ctx.Value(inUnitKey{}).(bool)
+	return v
+}
+
+type failingGranter struct{ err error }
+
+func (g failingGranter) GrantSignup(ctx context.Context, userID string) error { return g.err }
+func (g failingGranter) GrantPromos(ctx context.Context, userID string) error { return g.err }
+func (g failingGranter) GrantFirstOrder(ctx context.Context, userID, orderNumber string) error {
+	return g.err
+}
+
+func TestUserService_Register_UnitOfWork(t *testing.T) {
+	grantErr := errors.New("grant failed")
+	var created []bool
+	repo := &mockUserRepo{CreateUserFunc: func(ctx context.Context, login, password string) (*models.User, error) {
+		created = append(created, inUnit(ctx))
+		return &models.User{ID: "1", Login: login}, nil
+	}}
+
+	svc := NewUserService(repo)
+	svc.SetCampaigns(failingGranter{err: grantErr})
+
+	// Without a transaction manager the account survives a failed grant.
+	user, err := svc.Register(context.Background(), "user", "pass", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", user.Login)
+
+	// In a unit of work the failed grant fails the whole sign-up.
+	tx := &fakeTxManager{}
+	svc.SetTxManager(tx)
+	_, err = svc.Register(context.Background(), "user", "pass", "")
+	assert.ErrorIs(t, err, grantErr)
+	assert.Equal(t, []bool{false, true}, created)
+	assert.Equal(t, 1, tx.runs)
+}
+
+func TestAccrualSyncService_Rescore_UnitOfWork(t *testing.T) {
+	repo := &mockOrderRepo{orders: map[string]models.Order{
+		"79927398713": {Number: "79927398713", UserID: "u1", Status: models.OrderStatusNew},
+	}}
+	tx := &fakeTxManager{attempts: 2}
+	svc := NewAccrualSyncService(repo, &mockLoyaltyService{resp: &models.OrderAccrualResponse{Status: "PROCESSED", Accrual: 500}})
+	svc.SetTxManager(tx)
+
+	_, err := svc.Rescore(context.Background(), "79927398713", true)
+	assert.NoError(t, err)
+	// Every attempt starts from scratch and repeats the update.
+	assert.Equal(t, 2, tx.runs)
+	assert.Len(t, repo.updates, 2)
+
+	grantErr := errors.New("grant failed")
+	svc.SetCampaigns(failingGranter{err: grantErr})
+	_, err = svc.Rescore(context.Background(), "79927398713", true)
+	assert.ErrorIs(t, err, grantErr)
+}
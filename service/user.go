@@ -3,7 +3,7 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
+	"log/slog"
 
 	"github.com/Guldana11/gophermart/models"
 	"github.com/Guldana11/gophermart/repository"
@@ -11,18 +11,80 @@ import (
 )
 
 type UserService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	campaigns CampaignGranter
+	referrals ReferralLinker
+	tx        repository.TxManager
+	logger    *slog.Logger
 }
 
 func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+	return &UserService{repo: repo, logger: slog.Default()}
 }
 
-func (s *UserService) Register(ctx context.Context, login, password string) (*models.User, error) {
+func (s *UserService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetCampaigns enables sign-up and promo grants. Without it registration and
+// login credit nothing.
+func (s *UserService) SetCampaigns(campaigns CampaignGranter) {
+	s.campaigns = campaigns
+}
+
+// SetTxManager makes registration atomic: the account, its referral link and
+// sign-up grant are created together or not at all. Without it the account is
+// kept when a grant fails.
+func (s *UserService) SetTxManager(tx repository.TxManager) {
+	s.tx = tx
+}
+
+// SetReferrals enables referral codes at registration. Without it any
+// referral code is rejected.
+func (s *UserService) SetReferrals(referrals ReferralLinker) {
+	s.referrals = referrals
+}
+
+// Register creates a member. A referral code, when given, is checked before
+// the account is created so a typo fails the sign-up instead of silently
+// losing the referral.
+func (s *UserService) Register(ctx context.Context, login, password, referralCode string) (*models.User, error) {
 	if login == "" || password == "" {
-		return nil, errors.New("login and password required")
+		return nil, ErrCredentialsRequired
 	}
-	user, err := s.repo.CreateUser(ctx, login, password)
+
+	var referrerID string
+	if referralCode != "" {
+		if s.referrals == nil {
+			return nil, ErrInvalidReferralCode
+		}
+		var err error
+		referrerID, err = s.referrals.ResolveCode(ctx, referralCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var user *models.User
+	err := inTx(ctx, s.tx, func(ctx context.Context) error {
+		var err error
+		user, err = s.repo.CreateUser(ctx, login, password)
+		if err != nil {
+			return err
+		}
+
+		if referrerID != "" {
+			err := sideEffect(ctx, s.tx, s.logger, "referral link failed", s.referrals.Link(ctx, referrerID, user.ID), "referee_id", user.ID, "referrer_id", referrerID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if s.campaigns != nil {
+			return sideEffect(ctx, s.tx, s.logger, "sign-up grant failed", s.campaigns.GrantSignup(ctx, user.ID), "member_id", user.ID)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +97,7 @@ func (s *UserService) Authenticate(ctx context.Context, login, password string)
 		return nil, err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, errors.New("invalid login/password")
+		return nil, ErrInvalidCredentials
 	}
 	return user, nil
 }
@@ -43,13 +105,23 @@ func (s *UserService) Authenticate(ctx context.Context, login, password string)
 func (s *UserService) Login(ctx context.Context, login, password string) (*models.User, error) {
 	user, err := s.repo.GetUserByLogin(ctx, login)
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
 	}
 
 	if !CheckPasswordHash(password, user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.BlockedAt != nil {
+		return nil, ErrUserBlocked
 	}
 
+	if s.campaigns != nil {
+		logFailure(ctx, s.logger, "promo grants failed", s.campaigns.GrantPromos(ctx, user.ID), "member_id", user.ID)
+	}
 	return user, nil
 }
 
@@ -119,7 +119,7 @@ func TestUserService_Register(t *testing.T) {
 				},
 			}
 			svc := NewUserService(mockRepo)
-			got, err := svc.Register(context.Background(), tt.login, tt.pass)
+			got, err := svc.Register(context.Background(), tt.login, tt.pass, "")
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// voucherCodeLength characters from referralAlphabet give about 79 bits,
+	// far beyond what can be guessed through the redeem endpoint.
+	voucherCodeLength = 16
+	voucherGroupSize  = 4
+	maxVoucherBatch   = 10000
+)
+
+type VoucherService struct {
+	repo repository.VoucherRepository
+	now  func() time.Time
+}
+
+func NewVoucherService(repo repository.VoucherRepository) *VoucherService {
+	return &VoucherService{repo: repo, now: time.Now}
+}
+
+// CreateBatch generates count codes worth value points each. The plain codes
+// are only in the result; the store keeps their hashes.
+func (s *VoucherService) CreateBatch(ctx context.Context, actor string, req models.CreateVoucherBatchRequest) (*models.CreatedVoucherBatch, error) {
+	now := s.now()
+	batch := models.VoucherBatch{
+		ID:        uuid.New().String(),
+		Name:      strings.TrimSpace(req.Name),
+		Value:     req.Value,
+		Count:     req.Count,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: actor,
+		CreatedAt: now,
+	}
+
+	if batch.Name == "" || batch.Value <= 0 || batch.Count < 1 || batch.Count > maxVoucherBatch {
+		return nil, ErrInvalidVoucherBatch
+	}
+	if batch.ExpiresAt != nil && !batch.ExpiresAt.After(now) {
+		return nil, ErrInvalidVoucherBatch
+	}
+
+	codes := make([]string, batch.Count)
+	hashes := make([]string, batch.Count)
+	for i := range codes {
+		code, err := randomCode(voucherCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = formatVoucherCode(code)
+		hashes[i] = HashToken(code)
+	}
+
+	if err := s.repo.CreateBatch(ctx, batch, hashes); err != nil {
+		return nil, err
+	}
+	return &models.CreatedVoucherBatch{VoucherBatch: batch, Codes: codes}, nil
+}
+
+func (s *VoucherService) Redeem(ctx context.Context, userID, code string) (*models.VoucherRedemption, error) {
+	code = normalizeVoucherCode(code)
+	if len(code) != voucherCodeLength {
+		return nil, ErrVoucherNotFound
+	}
+	return s.repo.RedeemVoucher(ctx, HashToken(code), userID, s.now())
+}
+
+func (s *VoucherService) Report(ctx context.Context) ([]models.VoucherBatchReport, error) {
+	return s.repo.BatchReports(ctx, s.now())
+}
+
+// formatVoucherCode groups a code as XXXX-XXXX-XXXX-XXXX for printing.
+func formatVoucherCode(code string) string {
+	var b strings.Builder
+	for i, r := range code {
+		if i > 0 && i%voucherGroupSize == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// normalizeVoucherCode accepts codes the way people type them: in any case,
+// with or without dashes and spaces.
+func normalizeVoucherCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code))
+}
+
+type VoucherServiceType interface {
+	CreateBatch(ctx context.Context, actor string, req models.CreateVoucherBatchRequest) (*models.CreatedVoucherBatch, error)
+	Redeem(ctx context.Context, userID, code string) (*models.VoucherRedemption, error)
+	Report(ctx context.Context) ([]models.VoucherBatchReport, error)
+}
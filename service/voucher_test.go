@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockVoucherRepo struct {
+	mu       sync.Mutex
+	vouchers map[string]*time.Time
+	batches  []models.VoucherBatch
+}
+
+func (m *mockVoucherRepo) CreateBatch(ctx context.Context, batch models.VoucherBatch, codeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.vouchers == nil {
+		m.vouchers = make(map[string]*time.Time)
+	}
+	for _, h := range codeHashes {
+		m.vouchers[h] = nil
+	}
+	m.batches = append(m.batches, batch)
+	return nil
+}
+
+func (m *mockVoucherRepo) RedeemVoucher(ctx context.Context, codeHash, userID string, now time.Time) (*models.VoucherRedemption, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	redeemedAt, ok := m.vouchers[codeHash]
+	if !ok {
+		return nil, ErrVoucherNotFound
+	}
+	if redeemedAt != nil {
+		return nil, ErrVoucherRedeemed
+	}
+	m.vouchers[codeHash] = &now
+	return &models.VoucherRedemption{Value: m.batches[0].Value, RedeemedAt: now}, nil
+}
+
+func (m *mockVoucherRepo) BatchReports(ctx context.Context, now time.Time) ([]models.VoucherBatchReport, error) {
+	return nil, nil
+}
+
+func TestVoucherService_CreateBatch(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		req     models.CreateVoucherBatchRequest
+		wantErr bool
+	}{
+		{"valid", models.CreateVoucherBatchRequest{Name: "Gift cards", Value: 500, Count: 25}, false},
+		{"no value", models.CreateVoucherBatchRequest{Name: "Gift cards", Count: 25}, true},
+		{"too many", models.CreateVoucherBatchRequest{Name: "Gift cards", Value: 500, Count: maxVoucherBatch + 1}, true},
+		{"already expired", models.CreateVoucherBatchRequest{Name: "Gift cards", Value: 500, Count: 1, ExpiresAt: &past}, true},
+	}
+
+	codeFormat := regexp.MustCompile(`^[A-Z2-9]{4}-[A-Z2-9]{4}-[A-Z2-9]{4}-[A-Z2-9]{4}$`)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockVoucherRepo{}
+			svc := NewVoucherService(repo)
+			svc.now = func() time.Time { return now }
+
+			batch, err := svc.CreateBatch(context.Background(), "user:admin", tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidVoucherBatch)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, batch.Codes, tt.req.Count)
+			assert.Len(t, repo.vouchers, tt.req.Count, "codes must be unique")
+			for _, code := range batch.Codes {
+				assert.Regexp(t, codeFormat, code)
+				_, stored := repo.vouchers[code]
+				assert.False(t, stored, "plain codes must not be stored")
+			}
+		})
+	}
+}
+
+func TestVoucherService_Redeem(t *testing.T) {
+	repo := &mockVoucherRepo{}
+	svc := NewVoucherService(repo)
+
+	batch, err := svc.CreateBatch(context.Background(), "user:admin", models.CreateVoucherBatchRequest{Name: "Promo", Value: 300, Count: 1})
+	assert.NoError(t, err)
+	code := batch.Codes[0]
+
+	_, err = svc.Redeem(context.Background(), "user-1", "AAAA-BBBB")
+	assert.ErrorIs(t, err, ErrVoucherNotFound)
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	results := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Some callers type the code without dashes.
+			typed := code
+			if i%2 == 1 {
+				typed = normalizeVoucherCode(code)
+			}
+			_, err := svc.Redeem(context.Background(), "user-1", typed)
+			results <- err
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	var ok, redeemed int
+	for err := range results {
+		switch {
+		case err == nil:
+			ok++
+		case assert.ErrorIs(t, err, ErrVoucherRedeemed):
+			redeemed++
+		}
+	}
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, attempts-1, redeemed)
+}
+
+func TestNormalizeVoucherCode(t *testing.T) {
+	assert.Equal(t, "ABCD2345EFGH6789", normalizeVoucherCode(" abcd-2345 efgh-6789 "))
+	assert.Equal(t, "ABCD-2345-EFGH-6789", formatVoucherCode("ABCD2345EFGH6789"))
+}
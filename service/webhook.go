@@ -0,0 +1,469 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Guldana11/gophermart/events"
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	webhookSecretPrefix  = "whsec_"
+	maxWebhooksPerOwner  = 10
+	webhookBatchSize     = 100
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+	webhookMaxBackoff    = time.Hour
+	// webhookLease is how long claimed deliveries are left to the worker that
+	// claimed them. It covers sending a whole batch.
+	webhookLease = 15 * time.Minute
+)
+
+type WebhookConfig struct {
+	// MaxAttempts is how often a delivery is tried before it is given up.
+	MaxAttempts int
+	// DisableAfter is how many attempts in a row may fail, across all of an
+	// endpoint's deliveries, before the endpoint is disabled.
+	DisableAfter int
+	Timeout      time.Duration
+	// AllowPrivateNetworks lets endpoints point at the internal and reserved
+	// ranges in blockedPrefixes, for local development. Otherwise members and
+	// merchants could use webhooks to reach the server's internal network.
+	AllowPrivateNetworks bool
+}
+
+// WebhookConfigFromEnv reads WEBHOOK_MAX_ATTEMPTS, WEBHOOK_DISABLE_AFTER,
+// WEBHOOK_TIMEOUT and WEBHOOK_ALLOW_PRIVATE_NETWORKS, falling back to 8, 20, 5s
+// and false.
+func WebhookConfigFromEnv(getenv func(string) string) (WebhookConfig, error) {
+	cfg := WebhookConfig{MaxAttempts: 8, DisableAfter: 20, Timeout: 5 * time.Second}
+
+	for name, dst := range map[string]*int{
+		"WEBHOOK_MAX_ATTEMPTS":  &cfg.MaxAttempts,
+		"WEBHOOK_DISABLE_AFTER": &cfg.DisableAfter,
+	} {
+		if v := getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = n
+		}
+	}
+
+	if v := getenv("WEBHOOK_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %q", v)
+		}
+		cfg.Timeout = d
+	}
+
+	if v := getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid WEBHOOK_ALLOW_PRIVATE_NETWORKS: %q", v)
+		}
+		cfg.AllowPrivateNetworks = b
+	}
+	return cfg, nil
+}
+
+// errBlockedAddress is what a delivery fails with when its endpoint's host
+// resolves to an address webhooks may not reach.
+var errBlockedAddress = errors.New("webhook address not allowed")
+
+// hostResolver looks up the addresses of a webhook endpoint's host.
+type hostResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// blockedPrefixes are the ranges webhooks may never be sent to: addresses
+// that reach this host, its network or the provider's infrastructure, such
+// as the 100.100.100.200 metadata endpoint in the shared address space, and
+// ranges that are reserved or not unicast.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // shared address space (CGNAT)
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, including broadcast
+	netip.MustParsePrefix("::/96"),           // unspecified, loopback, IPv4-compatible
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("fec0::/10"),       // site-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+var (
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour   = netip.MustParsePrefix("2002::/16")
+)
+
+// publicAddr reports whether webhooks may be sent to addr: it is in none of
+// blockedPrefixes, and neither is the IPv4 address a NAT64 or 6to4 address
+// embeds.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+
+	b := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return publicAddr(netip.AddrFrom4([4]byte(b[12:16])))
+	case sixToFour.Contains(addr):
+		return publicAddr(netip.AddrFrom4([4]byte(b[2:6])))
+	}
+	return true
+}
+
+// WebhookService registers webhook endpoints and delivers events to them. As
+// an EventPublisher it turns every relayed event into one delivery per
+// subscribed endpoint; Run then sends the deliveries, in order per endpoint.
+type WebhookService struct {
+	repo     repository.WebhookRepository
+	tx       repository.TxManager
+	cfg      WebhookConfig
+	client   *http.Client
+	resolver hostResolver
+	now      func() time.Time
+	logger   *slog.Logger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, tx repository.TxManager, cfg WebhookConfig) *WebhookService {
+	s := &WebhookService{
+		repo:     repo,
+		tx:       tx,
+		cfg:      cfg,
+		resolver: net.DefaultResolver,
+		now:      time.Now,
+		logger:   slog.Default(),
+	}
+
+	// Endpoints are checked when they are registered, but their host may
+	// resolve elsewhere by the time a delivery is sent, so every connection
+	// is checked again. There is no proxy: it would be the one dialled.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   s.checkDial,
+	}).DialContext
+	s.client = &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: otelhttp.NewTransport(transport),
+		// A redirect would send the signed payload somewhere the owner did
+		// not register.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return s
+}
+
+// checkDial refuses connections to addresses webhooks may not reach.
+func (s *WebhookService) checkDial(network, address string, _ syscall.RawConn) error {
+	if s.cfg.AllowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(addr) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+	return nil
+}
+
+// checkHost fails unless every address host resolves to may be reached.
+func (s *WebhookService) checkHost(ctx context.Context, host string) error {
+	if s.cfg.AllowPrivateNetworks {
+		return nil
+	}
+	addrs, err := s.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return ErrInvalidWebhook
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return ErrInvalidWebhook
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+func (s *WebhookService) CreateEndpoint(ctx context.Context, owner models.WebhookOwner, req models.CreateWebhookRequest) (*models.CreatedWebhookEndpoint, error) {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return nil, ErrInvalidWebhook
+	}
+	for _, eventType := range req.Events {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return nil, ErrInvalidWebhook
+		}
+	}
+	if err := s.checkHost(ctx, u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	n, err := s.repo.CountEndpoints(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxWebhooksPerOwner {
+		return nil, ErrTooManyWebhooks
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := webhookSecretPrefix + hex.EncodeToString(raw)
+
+	endpoint := models.WebhookEndpoint{
+		ID:         uuid.New().String(),
+		URL:        u.String(),
+		Events:     slices.Compact(slices.Sorted(slices.Values(req.Events))),
+		UserID:     owner.UserID,
+		MerchantID: owner.MerchantID,
+		CreatedAt:  s.now(),
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedWebhookEndpoint{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, owner models.WebhookOwner) ([]models.WebhookEndpoint, error) {
+	return s.repo.ListEndpoints(ctx, owner)
+}
+
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrWebhookNotFound
+	}
+	return s.repo.DeleteEndpoint(ctx, owner, id)
+}
+
+func (s *WebhookService) EnableEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrWebhookNotFound
+	}
+	return s.repo.EnableEndpoint(ctx, owner, id)
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, owner models.WebhookOwner, endpointID string, beforeID int64, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+	return s.repo.ListDeliveries(ctx, owner, endpointID, beforeID, limit)
+}
+
+// ReplayDelivery sends a delivery again, whether it succeeded or was given up.
+// The receiver gets the same event ID, so it can tell a replay from a new
+// event.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, owner models.WebhookOwner, endpointID string, id int64) error {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return ErrWebhookNotFound
+	}
+	return s.repo.ReplayDelivery(ctx, owner, endpointID, id, s.now())
+}
+
+// Publish queues event for the endpoints subscribed to it. The outbox relay
+// may publish an event twice; it is queued once per endpoint all the same.
+func (s *WebhookService) Publish(ctx context.Context, event models.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.EnqueueDeliveries(ctx, event, payload, s.now())
+	return err
+}
+
+// DeliverOnce sends one batch of due deliveries and returns how many
+// succeeded. It sends nothing while another worker is claiming deliveries.
+//
+// The batch is claimed, and leased for webhookLease, in a short unit of work.
+// Deliveries are sent with no transaction open and each outcome is recorded in
+// its own, so a slow receiver holds no locks.
+func (s *WebhookService) DeliverOnce(ctx context.Context) (int, error) {
+	now := s.now()
+
+	var deliveries []models.WebhookDelivery
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		claimed, ok, err := s.repo.ClaimDeliveries(ctx, now, now.Add(webhookLease), webhookBatchSize)
+		if err != nil || !ok {
+			deliveries = nil
+			return err
+		}
+		deliveries = claimed
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	failing := make(map[string]bool)
+	var released []int64
+	for i, d := range deliveries {
+		// Stop well before the lease runs out and another worker may claim
+		// the rest of the batch.
+		if s.now().Sub(now) > webhookLease/2 {
+			return delivered, s.release(ctx, nil, released, deliveries[i:], now)
+		}
+		if failing[d.EndpointID] {
+			released = append(released, d.ID)
+			continue
+		}
+
+		event := models.Event{ID: d.EventID, Type: d.EventType}
+		status, sendErr := events.Post(ctx, s.client, d.URL, d.Secret, s.now(), event, d.Payload)
+		if sendErr == nil && status >= 200 && status <= 299 {
+			if err := s.repo.MarkDelivered(ctx, d, status, s.now()); err != nil {
+				return delivered, s.release(ctx, err, released, deliveries[i+1:], now)
+			}
+			delivered++
+			continue
+		}
+
+		failing[d.EndpointID] = true
+		if err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			return s.recordFailure(ctx, d, status, sendErr, s.now())
+		}); err != nil {
+			return delivered, s.release(ctx, err, released, deliveries[i+1:], now)
+		}
+	}
+	return delivered, s.release(ctx, nil, released, nil, now)
+}
+
+// release hands back deliveries of the batch that were skipped or not
+// reached, so they need not wait for their lease to run out. Deliveries behind
+// a failed one stay held back by it.
+func (s *WebhookService) release(ctx context.Context, err error, skipped []int64, rest []models.WebhookDelivery, at time.Time) error {
+	for _, d := range rest {
+		skipped = append(skipped, d.ID)
+	}
+	if len(skipped) == 0 {
+		return err
+	}
+	return errors.Join(err, s.repo.ReleaseDeliveries(ctx, skipped, at))
+}
+
+func (s *WebhookService) recordFailure(ctx context.Context, d models.WebhookDelivery, status int, sendErr error, now time.Time) error {
+	reason := fmt.Sprintf("receiver responded %d", status)
+	if sendErr != nil {
+		reason = sendErr.Error()
+	}
+
+	var retryAt *time.Time
+	if d.Attempts+1 < s.cfg.MaxAttempts {
+		at := now.Add(webhookBackoff(d.Attempts))
+		retryAt = &at
+	}
+
+	failures, err := s.repo.MarkDeliveryFailed(ctx, d, status, reason, retryAt)
+	if err != nil {
+		return err
+	}
+	s.logger.WarnContext(ctx, "webhook delivery failed",
+		"endpoint_id", d.EndpointID, "delivery_id", d.ID, "attempts", d.Attempts+1, "error", reason)
+
+	if failures < s.cfg.DisableAfter {
+		return nil
+	}
+	disabled := fmt.Sprintf("%d consecutive failed deliveries, last: %s", failures, reason)
+	if err := s.repo.DisableEndpoint(ctx, d.EndpointID, disabled, now); err != nil {
+		return err
+	}
+	s.logger.WarnContext(ctx, "webhook endpoint disabled", "endpoint_id", d.EndpointID, "failures", failures)
+	return nil
+}
+
+// webhookBackoff waits 30s after the first failed attempt and doubles the wait
+// after every further one, up to webhookMaxBackoff.
+func webhookBackoff(attempts int) time.Duration {
+	if attempts >= 7 {
+		return webhookMaxBackoff
+	}
+	return min(30*time.Second<<attempts, webhookMaxBackoff)
+}
+
+// Run sends due deliveries every interval until ctx is done.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeliverOnce(ctx); err != nil {
+				s.logger.ErrorContext(ctx, "webhook worker failed", "error", err)
+			}
+		}
+	}
+}
+
+type WebhookServiceType interface {
+	CreateEndpoint(ctx context.Context, owner models.WebhookOwner, req models.CreateWebhookRequest) (*models.CreatedWebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, owner models.WebhookOwner) ([]models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error
+	EnableEndpoint(ctx context.Context, owner models.WebhookOwner, id string) error
+	ListDeliveries(ctx context.Context, owner models.WebhookOwner, endpointID string, beforeID int64, limit int) ([]models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, owner models.WebhookOwner, endpointID string, id int64) error
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Guldana11/gophermart/service"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// uniqueErrors names the domain error a duplicate in a given column stands
+// for. SQLite reports the column, not the constraint, as
+// "UNIQUE constraint failed: users.login".
+var uniqueErrors = map[string]error{
+	"users.login":              service.ErrLoginTaken,
+	"withdrawals.order_number": service.ErrInvalidOrder,
+	"orders.number":            service.ErrAlreadyUploadedOther,
+}
+
+// translateError maps driver errors onto the service error taxonomy the same
+// way the Postgres repositories do.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", service.ErrNotFound, err)
+	}
+
+	var sqlErr *sqlite.Error
+	if !errors.As(err, &sqlErr) {
+		return err
+	}
+
+	switch sqlErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		for column, target := range uniqueErrors {
+			if strings.Contains(sqlErr.Error(), column) {
+				return fmt.Errorf("%w: %w", target, err)
+			}
+		}
+		return fmt.Errorf("%w: %w", service.ErrConflict, err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Errorf("%w: %w", service.ErrInvalidReference, err)
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return service.Wrap(service.KindValidation, "value rejected by a check constraint", err)
+	}
+
+	// The busy timeout ran out, or a snapshot went stale under a writer.
+	if primary := sqlErr.Code() & 0xff; primary == sqlite3.SQLITE_BUSY || primary == sqlite3.SQLITE_LOCKED {
+		return fmt.Errorf("%w: %w", service.ErrSerialization, err)
+	}
+	return err
+}
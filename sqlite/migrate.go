@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// The SQLite schema is migrated separately from the Postgres one: it starts
+// from the current shape of the tables it has instead of replaying history.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Migrate applies every pending migration.
+func Migrate(uri string) error {
+	m, err := newMigrate(uri)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	return nil
+}
+
+// MigrateDown reverts the last steps migrations.
+func MigrateDown(uri string, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	m, err := newMigrate(uri)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Steps(-steps); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	return nil
+}
+
+// ForceMigrationVersion records version as applied and clears the dirty flag
+// without running any SQL.
+func ForceMigrationVersion(uri string, version int) error {
+	m, err := newMigrate(uri)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return m.Force(version)
+}
+
+// MigrationVersion reports the schema version currently applied. ok is false
+// when no migration has been run yet.
+func MigrationVersion(uri string) (version uint, dirty bool, ok bool, err error) {
+	m, err := newMigrate(uri)
+	if err != nil {
+		return 0, false, false, err
+	}
+	defer m.Close()
+
+	version, dirty, err = m.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, err
+	}
+	return version, dirty, true, nil
+}
+
+// LatestMigrationVersion returns the newest migration built into the binary.
+func LatestMigrationVersion() (uint, error) {
+	src, err := migrationSource()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("no migrations embedded: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// SchemaVersion reads the applied schema version through an open database,
+// for readiness probes.
+func SchemaVersion(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error) {
+	var v int64
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&v, &dirty)
+	if err != nil {
+		return 0, false, translateError(err)
+	}
+	return uint(v), dirty, nil
+}
+
+func migrationSource() (source.Driver, error) {
+	src, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+	return src, nil
+}
+
+// newMigrate opens a connection of its own; closing the returned instance
+// closes it.
+func newMigrate(uri string) (*migrate.Migrate, error) {
+	src, err := migrationSource()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := Open(context.Background(), uri)
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migrate driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS withdrawals;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS user_points;
+DROP TABLE IF EXISTS users;
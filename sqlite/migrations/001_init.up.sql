@@ -0,0 +1,42 @@
+-- Amounts are stored as integer cents. REAL columns would drift as balances
+-- are credited and debited, where Postgres keeps NUMERIC(12,2) exact.
+CREATE TABLE IF NOT EXISTS users (
+    id            TEXT PRIMARY KEY,
+    login         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    loyalty_card  TEXT NOT NULL UNIQUE,
+    referral_code TEXT NOT NULL UNIQUE,
+    role          TEXT NOT NULL DEFAULT 'user',
+    blocked_at    TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_points (
+    user_id          TEXT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    current_balance  INTEGER NOT NULL DEFAULT 0,
+    withdrawn_points INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    number      TEXT NOT NULL UNIQUE
+        CONSTRAINT chk_number_digits CHECK (number <> '' AND number NOT GLOB '*[^0-9]*'),
+    user_id     TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status      TEXT NOT NULL DEFAULT 'NEW'
+        CONSTRAINT chk_orders_status CHECK (status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED')),
+    accrual     INTEGER NOT NULL DEFAULT 0,
+    bonus       INTEGER NOT NULL DEFAULT 0,
+    uploaded_at TIMESTAMP NOT NULL,
+    CONSTRAINT chk_orders_amounts CHECK (accrual >= 0 AND bonus >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_uploaded ON orders (user_id, uploaded_at);
+
+CREATE TABLE IF NOT EXISTS withdrawals (
+    order_number TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    sum          INTEGER NOT NULL CONSTRAINT chk_withdrawals_sum CHECK (sum > 0),
+    processed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawals_user_processed ON withdrawals (user_id, processed_at);
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+)
+
+var _ repository.OrderRepository = (*OrderRepo)(nil)
+
+type OrderRepo struct {
+	db *sql.DB
+}
+
+func NewOrderRepo(db *sql.DB) *OrderRepo {
+	return &OrderRepo{db: db}
+}
+
+func (r *OrderRepo) CheckOrderExists(ctx context.Context, orderNumber string) (string, bool, error) {
+	var userID string
+	err := r.db.QueryRowContext(ctx, "SELECT user_id FROM orders WHERE number = ?", orderNumber).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, translateError(err)
+	}
+	return userID, true, nil
+}
+
+func (r *OrderRepo) CreateOrder(ctx context.Context, order models.Order) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO orders (user_id, number, uploaded_at) VALUES (?, ?, ?)",
+		order.UserID, order.Number, time.Now().UTC(),
+	)
+	return translateError(err)
+}
+
+func (r *OrderRepo) GetOrdersByUser(ctx context.Context, userID string) ([]models.Order, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT number, status, accrual, bonus, uploaded_at
+         FROM orders
+         WHERE user_id = ?
+         ORDER BY uploaded_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var o models.Order
+		var accrual, bonus int64
+		if err := rows.Scan(&o.Number, &o.Status, &accrual, &bonus, &o.UploadedAt); err != nil {
+			return nil, translateError(err)
+		}
+		o.Accrual, o.Bonus = fromCents(accrual), fromCents(bonus)
+		orders = append(orders, o)
+	}
+	return orders, translateError(rows.Err())
+}
+
+func (r *OrderRepo) GetOrder(ctx context.Context, orderNumber string) (*models.Order, error) {
+	var o models.Order
+	var accrual, bonus int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, number, user_id, status, accrual, bonus, uploaded_at
+         FROM orders
+         WHERE number = ?`,
+		orderNumber,
+	).Scan(&o.ID, &o.Number, &o.UserID, &o.Status, &accrual, &bonus, &o.UploadedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrOrderNotFound
+		}
+		return nil, translateError(err)
+	}
+	o.Accrual, o.Bonus = fromCents(accrual), fromCents(bonus)
+	return &o, nil
+}
+
+func (r *OrderRepo) GetPendingOrders(ctx context.Context, after string, limit int) ([]models.Order, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, number, user_id, status, accrual, bonus, uploaded_at
+         FROM orders
+         WHERE status IN ('NEW', 'PROCESSING') AND number > ?
+         ORDER BY number
+         LIMIT ?`, after, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var orders []models.Order
+	for rows.Next() {
+		var o models.Order
+		var accrual, bonus int64
+		if err := rows.Scan(&o.ID, &o.Number, &o.UserID, &o.Status, &accrual, &bonus, &o.UploadedAt); err != nil {
+			return nil, translateError(err)
+		}
+		o.Accrual, o.Bonus = fromCents(accrual), fromCents(bonus)
+		orders = append(orders, o)
+	}
+	return orders, translateError(rows.Err())
+}
+
+// UpdateOrderAccrual stores the accrual system's verdict for an order and, in
+// the same transaction, credits the member with the difference between the
+// new and the previously credited accrual plus bonus.
+func (r *OrderRepo) UpdateOrderAccrual(ctx context.Context, orderNumber, status string, accrual, bonus float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback()
+
+	var userID, oldStatus string
+	var oldAccrual, oldBonus int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT user_id, status, accrual, bonus FROM orders WHERE number = ?",
+		orderNumber,
+	).Scan(&userID, &oldStatus, &oldAccrual, &oldBonus)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service.ErrOrderNotFound
+		}
+		return translateError(err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE orders SET status = ?, accrual = ?, bonus = ? WHERE number = ?",
+		status, toCents(accrual), toCents(bonus), orderNumber,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	delta := creditedAccrual(status, toCents(accrual)+toCents(bonus)) - creditedAccrual(oldStatus, oldAccrual+oldBonus)
+	if delta != 0 {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO user_points (user_id, current_balance, withdrawn_points)
+             VALUES (?, ?, 0)
+             ON CONFLICT (user_id) DO UPDATE
+             SET current_balance = current_balance + excluded.current_balance`,
+			userID, delta,
+		)
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	return translateError(tx.Commit())
+}
+
+func creditedAccrual(status string, accrual int64) int64 {
+	if status != models.OrderStatusProcessed {
+		return 0
+	}
+	return accrual
+}
+
+// CountOrdersByStatus reports how many orders are in each status. Statuses
+// without orders are left out.
+func (r *OrderRepo) CountOrdersByStatus(ctx context.Context) (map[string]int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT status, count(*) FROM orders GROUP BY status")
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, translateError(err)
+		}
+		counts[status] = n
+	}
+	return counts, translateError(rows.Err())
+}
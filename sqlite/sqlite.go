@@ -0,0 +1,75 @@
+// Package sqlite stores users, orders and balances in a SQLite file, for
+// single-node deployments that do not run Postgres. It has its own
+// migrations and implements the repositories the member API needs.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Scheme prefixes the DATABASE_URI of a SQLite database, as in
+// sqlite:///var/lib/gophermart/loyalty.db or sqlite://loyalty.db for a path
+// relative to the working directory.
+const Scheme = "sqlite://"
+
+// IsURI reports whether uri names a SQLite database.
+func IsURI(uri string) bool {
+	return strings.HasPrefix(uri, Scheme)
+}
+
+// connParams configure every connection. SQLite allows one writer at a time:
+// transactions begin IMMEDIATE so they take the write lock up front, and
+// busy_timeout makes a second writer wait for it instead of failing. A
+// deferred transaction would read the balance, then fail to upgrade its lock
+// when a concurrent withdrawal got there first. WAL lets readers carry on
+// while a write is in progress.
+const connParams = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_pragma=synchronous(NORMAL)&_txlock=immediate&_time_format=sqlite"
+
+func dsn(uri string) (string, error) {
+	if !IsURI(uri) {
+		return "", fmt.Errorf("not a SQLite URI: %q", uri)
+	}
+
+	path, query, _ := strings.Cut(strings.TrimPrefix(uri, Scheme), "?")
+	if path == "" {
+		return "", fmt.Errorf("SQLite URI %q has no file path", uri)
+	}
+	if query != "" {
+		query += "&"
+	}
+	return "file:" + path + "?" + query + connParams, nil
+}
+
+// Open opens the database file, creating it when it does not exist yet.
+func Open(ctx context.Context, uri string) (*sql.DB, error) {
+	source, err := dsn(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", source)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", uri, err)
+	}
+	return db, nil
+}
+
+// Amounts are stored as integer cents, which keeps balances exact the way
+// NUMERIC(12,2) does in Postgres; REAL columns would drift.
+func toCents(points float64) int64 {
+	return int64(math.Round(points * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
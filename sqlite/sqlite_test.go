@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository/repositorytest"
+	"github.com/Guldana11/gophermart/service"
+)
+
+func openTestDB(t *testing.T) string {
+	t.Helper()
+	uri := Scheme + filepath.Join(t.TempDir(), "loyalty.db")
+	if err := Migrate(uri); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return uri
+}
+
+func TestConformance(t *testing.T) {
+	db, err := Open(context.Background(), openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Backend {
+		return repositorytest.Backend{Users: NewUserRepo(db), Orders: NewOrderRepo(db)}
+	})
+}
+
+// TestUserRepo_Withdraw_Concurrent spends the same balance from many
+// connections at once: exactly as many withdrawals as the balance covers may
+// succeed, and none may fail on a locked database.
+func TestUserRepo_Withdraw_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(ctx, openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	users, orders := NewUserRepo(db), NewOrderRepo(db)
+	user, err := users.CreateUser(ctx, "member", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.CreateOrder(ctx, models.Order{UserID: user.ID, Number: "79927398713"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := orders.UpdateOrderAccrual(ctx, "79927398713", models.OrderStatusProcessed, 500, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 20
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make(chan error, attempts)
+	)
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- users.Withdraw(ctx, user.ID, fmt.Sprintf("%d", 1000+i), 100)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, service.ErrInsufficientFunds):
+			t.Errorf("Withdraw() error = %v, expected success or %v", err, service.ErrInsufficientFunds)
+		}
+	}
+	if succeeded != 5 {
+		t.Errorf("%d withdrawals of 100 succeeded from a balance of 500, expected 5", succeeded)
+	}
+
+	current, withdrawn, err := users.GetUserPoints(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != 0 || withdrawn != 500 {
+		t.Errorf("balance = %v/%v, expected 0/500", current, withdrawn)
+	}
+}
+
+func TestMigrations_RoundTrip(t *testing.T) {
+	uri := openTestDB(t)
+
+	latest, err := LatestMigrationVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, dirty, ok, err := MigrationVersion(uri)
+	if err != nil || !ok || dirty || version != latest {
+		t.Fatalf("MigrationVersion() = %d, %t, %t, %v, expected %d", version, dirty, ok, err, latest)
+	}
+
+	if err := MigrateDown(uri, int(latest)); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+	if err := Migrate(uri); err != nil {
+		t.Fatalf("Migrate() after down error = %v", err)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	got, err := dsn("sqlite:///var/lib/loyalty.db?cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "file:/var/lib/loyalty.db?cache=shared&" + connParams; got != want {
+		t.Errorf("dsn() = %q, expected %q", got, want)
+	}
+
+	for _, bad := range []string{"postgres://localhost/loyalty", "sqlite://"} {
+		if _, err := dsn(bad); err == nil {
+			t.Errorf("dsn(%q) accepted an invalid URI", bad)
+		}
+	}
+}
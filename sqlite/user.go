@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Guldana11/gophermart/models"
+	"github.com/Guldana11/gophermart/repository"
+	"github.com/Guldana11/gophermart/service"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ repository.UserRepository = (*UserRepo)(nil)
+
+const userColumns = `id, login, password_hash, loyalty_card, referral_code, role, blocked_at, created_at`
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+// CreateUser inserts the user and their points row in one transaction. A
+// duplicate login is caught by the unique constraint on users.login.
+func (r *UserRepo) CreateUser(ctx context.Context, login, password string) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := service.NewLoyaltyCardNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	referralCode, err := service.NewReferralCode()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		ID:           uuid.New().String(),
+		Login:        login,
+		PasswordHash: string(hash),
+		LoyaltyCard:  card,
+		ReferralCode: referralCode,
+		Role:         models.RoleUser,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO users (id, login, password_hash, loyalty_card, referral_code, role, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		user.ID, user.Login, user.PasswordHash, user.LoyaltyCard, user.ReferralCode, user.Role, user.CreatedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES (?, 0, 0)",
+		user.ID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, translateError(err)
+	}
+	return user, nil
+}
+
+func (r *UserRepo) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	var u models.User
+	err := r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE login = ?", login).
+		Scan(&u.ID, &u.Login, &u.PasswordHash, &u.LoyaltyCard, &u.ReferralCode, &u.Role, &u.BlockedAt, &u.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrUserNotFound
+		}
+		return nil, translateError(err)
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) GetUserPoints(ctx context.Context, userID string) (float64, float64, error) {
+	var current, withdrawn int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT current_balance, withdrawn_points FROM user_points WHERE user_id = ?", userID).
+		Scan(&current, &withdrawn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_, err := r.db.ExecContext(ctx,
+				`INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES (?, 0, 0)
+                 ON CONFLICT (user_id) DO NOTHING`, userID)
+			return 0, 0, translateError(err)
+		}
+		return 0, 0, translateError(err)
+	}
+	return fromCents(current), fromCents(withdrawn), nil
+}
+
+// Withdraw checks the balance and debits it in one transaction. Transactions
+// begin IMMEDIATE, so a concurrent withdrawal waits for this one to commit
+// before it reads the balance; see connParams.
+func (r *UserRepo) Withdraw(ctx context.Context, userID string, order string, sum float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM withdrawals WHERE order_number = ?)", order).Scan(&exists)
+	if err != nil {
+		return translateError(err)
+	}
+	if exists {
+		return service.ErrInvalidOrder
+	}
+
+	var current int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT current_balance FROM user_points WHERE user_id = ?", userID).Scan(&current)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return translateError(err)
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO user_points (user_id, current_balance, withdrawn_points) VALUES (?, 0, 0)", userID)
+		if err != nil {
+			return translateError(err)
+		}
+	}
+
+	cents := toCents(sum)
+	if cents > current {
+		return service.ErrInsufficientFunds
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE user_points
+         SET current_balance = current_balance - ?,
+             withdrawn_points = withdrawn_points + ?
+         WHERE user_id = ?`,
+		cents, cents, userID,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO withdrawals (user_id, order_number, sum, processed_at) VALUES (?, ?, ?, ?)",
+		userID, order, cents, time.Now().UTC(),
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return translateError(tx.Commit())
+}
+
+func (r *UserRepo) GetUserWithdrawals(ctx context.Context, userID string) ([]models.Withdrawal, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT order_number, sum, processed_at
+		 FROM withdrawals
+		 WHERE user_id = ?
+		 ORDER BY processed_at DESC, rowid DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	withdrawals := make([]models.Withdrawal, 0)
+	for rows.Next() {
+		var w models.Withdrawal
+		var sum int64
+		if err := rows.Scan(&w.OrderNumber, &sum, &w.ProcessedAt); err != nil {
+			return nil, translateError(err)
+		}
+		w.Sum = fromCents(sum)
+		withdrawals = append(withdrawals, w)
+	}
+	return withdrawals, translateError(rows.Err())
+}
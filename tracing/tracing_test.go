@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	env := func(vals map[string]string) func(string) string {
+		return func(k string) string { return vals[k] }
+	}
+
+	cfg, err := ConfigFromEnv(env(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, Config{Exporter: ExporterNone, ServiceName: "gophermart"}, cfg)
+
+	cfg, err = ConfigFromEnv(env(map[string]string{"TRACING_EXPORTER": "OTLP", "OTEL_SERVICE_NAME": "loyalty"}))
+	assert.NoError(t, err)
+	assert.Equal(t, Config{Exporter: ExporterOTLP, ServiceName: "loyalty"}, cfg)
+
+	_, err = ConfigFromEnv(env(map[string]string{"TRACING_EXPORTER": "jaeger"}))
+	assert.Error(t, err)
+}
+
+func TestPgxTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	var tracer PgxTracer
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "  select id FROM users WHERE login = $1",
+		Args: []any{"alice"},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "db SELECT", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		for _, attr := range spans[0].Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), "alice")
+		}
+	}
+}